@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/platform9/appctl/pkg/appManageAPI"
 	"github.com/platform9/appctl/pkg/constants"
+	"github.com/ryanuber/columnize"
 	"github.com/spf13/cobra"
 )
 
@@ -18,16 +20,34 @@ var deleteExample = `
 
   # Force delete an app using app-name and force flag.
   appctl delete -n <appname> -f
+
+  # Delete an app without asking for confirmation, eg. from a pipeline.
+  appctl delete -n <appname> --yes
+
+  # Delete several apps by name.
+  appctl delete <appname1> <appname2> <appname3>
+
+  # Delete all apps with a label, or without it.
+  appctl delete --selector event=hackathon
+  appctl delete --selector '!keep'
+
+  # Delete all apps created more than a week ago.
+  appctl delete --older-than 7d
+
+  # Delete all apps, 8 at a time.
+  appctl delete --all --parallel 8
  `
 
 // appCmdDelete -- To delete an existing app.
 var (
 	appCmdDelete = &cobra.Command{
-		Use:     "delete",
-		Short:   "Delete an existing app",
+		Use:     "delete [appname...]",
+		Short:   "Delete existing apps",
 		Example: deleteExample,
-		Long:    `Delete an existing app`,
-		Run:     appCmdDeleteRun,
+		Long:    `Delete existing apps, by name or by selecting them with a label selector or age`,
+		RunE:    appCmdDeleteRun,
+		// Complete the names of the apps to delete.
+		ValidArgsFunction: completeAppNames,
 	}
 )
 
@@ -37,73 +57,156 @@ var (
 	appNameDelete string
 	// To force delete an app.
 	force bool
+	// To delete without asking for confirmation.
+	assumeYes bool
 	//Choice to delete app
 	deleteConfirmChoice string
+	// To select apps to delete.
+	deleteSelector  string
+	deleteOlderThan string
+	deleteAll       bool
+	// Number of apps deleted at a time.
+	deleteParallel int
 )
 
 func init() {
 	rootCmd.AddCommand(appCmdDelete)
 	appCmdDelete.Flags().StringVarP(&appNameDelete, "app-name", "n", "", "Provide the name of app to be deleted")
+	appCmdDelete.RegisterFlagCompletionFunc("app-name", completeAppNames)
 	appCmdDelete.Flags().BoolVarP(&force, "force", "f", false, "To force delete an app")
+	appCmdDelete.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Delete without asking for confirmation")
+	appCmdDelete.Flags().StringVarP(&deleteSelector, "selector", "l", "", "Delete apps matching the label selector, eg. key1=value1,key2!=value2")
+	appCmdDelete.Flags().StringVar(&deleteOlderThan, "older-than", "", "Delete apps created before this age, eg. 7d, 2w or 36h")
+	appCmdDelete.Flags().BoolVar(&deleteAll, "all", false, "Delete all apps")
+	appCmdDelete.Flags().IntVar(&deleteParallel, "parallel", 4, "Number of apps to delete at a time")
 }
 
-// To delete an app by its name.
-func appCmdDeleteRun(cmd *cobra.Command, args []string) {
-	if appNameDelete == "" {
-		fmt.Printf("App name not specified.\n")
-		return
+// To delete apps by name, or selected by label or age.
+func appCmdDeleteRun(cmd *cobra.Command, args []string) error {
+	names := args
+	if appNameDelete != "" {
+		names = append(names, appNameDelete)
 	}
-	// To ask user if to delete app when force delete is false.
-	if !(force) {
-		var count = 0
-		for count < 3 {
-			count++
-			// To make sure delete the app
-			reader := bufio.NewReader(os.Stdin)
-			fmt.Printf("Are you sure you want to delete app (y/n)? ")
-			deleteConfirmChoice, _ = reader.ReadString('\n')
-			deleteConfirmChoice = strings.TrimSuffix(deleteConfirmChoice, "\n")
-			deleteConfirmChoice = strings.TrimSuffix(deleteConfirmChoice, "\r")
-
-			// If response is other than "y" or "n"
-			if deleteConfirmChoice != "y" && deleteConfirmChoice != "n" {
-				fmt.Printf("Please enter correct input (y/n).\n")
-				continue
-			}
-			// To delete app if Yes
-			if deleteConfirmChoice == "y" {
-				// Validate app name.
-				if !constants.RegexValidate(appNameDelete, constants.ValidAppNameRegex) {
-					fmt.Printf("Invalid app name.\n")
-					return
-				}
-				errapi := appManageAPI.DeleteApp(appNameDelete)
-				if errapi != nil {
-					fmt.Printf("%v", errapi)
-					return
-				}
-				fmt.Printf("Successfully deleted the app: %v\n", appNameDelete)
-				break
-			}
-			// To stop delete app process if No
-			if deleteConfirmChoice == "n" {
-				fmt.Printf("You have cancelled the app deletion activity!!\n")
-				break
-			}
+	selecting := deleteSelector != "" || deleteOlderThan != "" || deleteAll
+
+	if len(names) > 0 && selecting {
+		return fmt.Errorf("app names can't be combined with --selector, --older-than or --all")
+	}
+	if len(names) == 0 && !selecting {
+		return fmt.Errorf("app name not specified, give it with --app-name, or use --selector, --older-than or --all")
+	}
+	// Without prompts, the deletion must be confirmed with a flag.
+	if !(force || assumeYes) && !promptsEnabled() {
+		return missingFlagError("yes")
+	}
+
+	if selecting {
+		olderThan, err := parseOlderThan()
+		if err != nil {
+			return err
+		}
+		names, err = appManageAPI.SelectApps(deleteSelector, olderThan)
+		if err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			fmt.Printf("No apps found to delete.\n")
+			return nil
 		}
-	} else {
-		// Validate app name.
-		if !constants.RegexValidate(appNameDelete, constants.ValidAppNameRegex) {
-			fmt.Printf("Invalid app name.\n")
-			return
+	}
+
+	// Validate app names.
+	for _, name := range names {
+		if !constants.RegexValidate(name, constants.ValidAppNameRegex) {
+			return fmt.Errorf("invalid app name: %v", name)
 		}
-		// If force delete an app.
-		errapi := appManageAPI.DeleteApp(appNameDelete)
+	}
+
+	// To ask user if to delete apps when force delete is false.
+	if !(force || assumeYes) && !confirmDelete(names) {
+		return nil
+	}
+
+	if len(names) == 1 {
+		errapi := appManageAPI.DeleteApp(names[0])
 		if errapi != nil {
-			fmt.Printf("%v", errapi)
-			return
+			return errapi
 		}
-		fmt.Printf("Successfully deleted the app: %v\n", appNameDelete)
+		fmt.Printf("Successfully deleted the app: %v\n", names[0])
+		return nil
 	}
 
+	results, errapi := appManageAPI.DeleteApps(names, deleteParallel)
+	if errapi != nil {
+		return errapi
+	}
+	return printDeleteSummary(results)
+}
+
+// To parse --older-than, zero if not set.
+func parseOlderThan() (time.Duration, error) {
+	if deleteOlderThan == "" {
+		return 0, nil
+	}
+	return appManageAPI.ParseAge(deleteOlderThan)
+}
+
+// To ask the user once to confirm deleting all the apps.
+func confirmDelete(names []string) bool {
+	if len(names) > 1 {
+		fmt.Printf("The following %d apps will be deleted:\n", len(names))
+		for _, name := range names {
+			fmt.Printf("  %v\n", name)
+		}
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	var count = 0
+	for count < 3 {
+		count++
+		// To make sure delete the apps
+		if len(names) > 1 {
+			fmt.Printf("Are you sure you want to delete these %d apps (y/n)? ", len(names))
+		} else {
+			fmt.Printf("Are you sure you want to delete app (y/n)? ")
+		}
+		deleteConfirmChoice, _ = reader.ReadString('\n')
+		deleteConfirmChoice = strings.TrimSuffix(deleteConfirmChoice, "\n")
+		deleteConfirmChoice = strings.TrimSuffix(deleteConfirmChoice, "\r")
+
+		// If response is other than "y" or "n"
+		if deleteConfirmChoice != "y" && deleteConfirmChoice != "n" {
+			fmt.Printf("Please enter correct input (y/n).\n")
+			continue
+		}
+		// To delete apps if Yes
+		if deleteConfirmChoice == "y" {
+			return true
+		}
+		// To stop delete app process if No
+		fmt.Printf("You have cancelled the app deletion activity!!\n")
+		return false
+	}
+	return false
+}
+
+// To print the result of deleting each app. Fails if some weren't deleted.
+func printDeleteSummary(results []appManageAPI.DeleteResult) error {
+	output := []string{"NAME | RESULT"}
+	deleted := 0
+	for _, result := range results {
+		status := "Deleted"
+		if result.Err != nil {
+			status = fmt.Sprintf("Failed: %v", strings.TrimSpace(result.Err.Error()))
+		} else {
+			deleted++
+		}
+		output = append(output, fmt.Sprintf("%v | %v", result.Name, status))
+	}
+	fmt.Println(columnize.SimpleFormat(output))
+	fmt.Printf("\nSuccessfully deleted %d of %d apps.\n", deleted, len(results))
+	if deleted < len(results) {
+		return fmt.Errorf("%d of %d apps couldn't be deleted", len(results)-deleted, len(results))
+	}
+	return nil
 }
@@ -2,11 +2,16 @@ package cmd
 
 import (
 	"bufio"
+	"context"
+	"crypto/ecdsa"
 	"fmt"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/platform9/appctl/pkg/appAPIs"
 	"github.com/platform9/appctl/pkg/appManageAPI"
 	"github.com/platform9/appctl/pkg/constants"
 	"github.com/spf13/cobra"
@@ -38,6 +43,22 @@ var deployExample = `
 	  appctl deploy -n <appname> -i <registry name>.azurecr.io/<image>:<tag> -u <service principal appId> -P <service principal password>
 
 
+  # Deploy the tag as given, to run whatever it points to when the app starts.
+  # By default the tag is resolved to the digest it points to now.
+  appctl deploy -n <appname> -i <image>:<tag> --keep-tag
+
+  # Deploy an app, and deploy it again each time the tag is pushed, until interrupted.
+  appctl deploy -n <appname> -i <image>:dev --follow-tag --poll-interval 1m
+
+  # Deploy an app only if its image is signed with the cosign key.
+  appctl deploy -n <appname> -i <image>:<tag> --verify-signature --key cosign.pub
+
+  # Deploy an app with labels, checked against a deployment policy.
+  appctl deploy -n <appname> -i <image>:<tag> --label team=payments --policy policy.yaml
+
+  # Deploy an app from a private registry, with credentials added once with appctl registry add.
+  appctl deploy -n <appname> -i ghcr.io/<org>/<image>:<tag> --registry <registry name>
+
   # Deploy an app using app-name and container image, and pass environment variables.
   # Assumes the container has a server that will listen on port 8080
   appctl deploy -n <appname> -i <image> -e key1=value1 -e key2=value2
@@ -53,6 +74,34 @@ var deployExample = `
   # Deploy an app using app-name, container image and pass environment variables through a file and pass through command line and set port where application listens on.
   appctl deploy -n <appname> -i <image> -f <env-file-path> -e key1=value1 -e key2=value2 -p <port>
   Ex: appctl deploy -n hello -i gcr.io/knative-samples/helloworld-go -f /Users/user/variables.env -e TARGET="appctler" -p 7893
+
+  # Deploy an app with env values read from secret providers at deploy time: a file,
+  # an env variable of appctl, a command's output (run without a shell), or vault.
+  # Start a value with @@ for a literal value starting with @.
+  appctl deploy -n <appname> -i <image> -e DB_PASS=@file:/run/secrets/db -e API_KEY=@env:API_KEY
+  appctl deploy -n <appname> -i <image> -e DB_PASS="@cmd:pass show db" -e TOKEN=@vault:secret/data/app#token
+
+  # Read the secrets referenced in the env file too, its values are literal otherwise.
+  appctl deploy -n <appname> -i <image> -f <env-file-path> --resolve-secrets
+
+  # Deploy an app from a pipeline, without prompting for missing values.
+  # Prompts are also disabled when stdin is not a terminal.
+  appctl deploy -n <appname> -i <image> --non-interactive
+
+  # Deploy a gRPC app, served over HTTP/2 on port 50051.
+  appctl deploy -n <appname> -i <image> -p 50051 --protocol h2c
+
+  # Deploy a worker from the same image as a web app, with another command and arguments.
+  appctl deploy -n <appname> -i <image> --command python --arg -m --arg worker
+
+  # Deploy an app with half a core and 512Mi of memory, allowed to burst to a core and 1Gi.
+  appctl deploy -n <appname> -i <image> --cpu 500m --memory 512Mi --cpu-limit 1 --memory-limit 1Gi
+
+  # Deploy an app that never scales to zero, with up to 5 replicas of 50 concurrent requests each.
+  appctl deploy -n <appname> -i <image> --min-scale 1 --max-scale 5 --concurrency-target 50
+
+  # Deploy an app that takes requests once /ready answers, and is restarted when /healthz stops answering.
+  appctl deploy -n <appname> -i <image> --readiness-path /ready --liveness-path /healthz --probe-period 10 --probe-timeout 2
   `
 
 // appCmdDeploy - To deploy an app.
@@ -62,7 +111,7 @@ var (
 		Short:   "Deploy an app",
 		Example: deployExample,
 		Long:    `Deploy an app`,
-		Run:     appCmdDeployRun,
+		RunE:    appCmdDeployRun,
 	}
 )
 
@@ -74,6 +123,47 @@ type App struct {
 	userName    string
 	password    string
 	envFilePath string
+	// Registry credentials added with `appctl registry add`.
+	registry string
+	// To deploy without checking the image in its registry first.
+	skipImageCheck bool
+	// To deploy the tag as given, instead of the digest it points to.
+	keepTag bool
+	// To deploy again each time the tag moves, checking it every pollInterval.
+	followTag    bool
+	pollInterval time.Duration
+	// To refuse images not signed with the cosign public key at keyPath.
+	verifySignature bool
+	keyPath         string
+	// Labels of the app, as key=value pairs.
+	labels []string
+	// Deployment policy the app is checked against, the default one if empty.
+	policyPath string
+	// To show the values of env variables that look like secrets in errors.
+	showSecrets bool
+	// To resolve the secret references in the env file too.
+	resolveSecrets bool
+	// Protocol of the port, http1 or h2c.
+	protocol string
+	// Entrypoint of the container and its arguments.
+	command string
+	args    []string
+	// Resources of the container, as Kubernetes quantities.
+	cpu         string
+	memory      string
+	cpuLimit    string
+	memoryLimit string
+	// Autoscaling of the app, only sent if the flags are set.
+	minScale             int
+	maxScale             int
+	concurrencyTarget    int
+	containerConcurrency int
+	scaleDownDelay       string
+	// HTTP probes of the container.
+	readinessPath string
+	livenessPath  string
+	probePeriod   int
+	probeTimeout  int
 }
 
 // command variables
@@ -87,13 +177,120 @@ func init() {
 	appCmdDeploy.Flags().StringVarP(&deployApp.image, "image", "i", "", "Container image of the app (public / private registry path)")
 	appCmdDeploy.Flags().StringVarP(&deployApp.userName, "username", "u", "", "Username of private container registry")
 	appCmdDeploy.Flags().StringVarP(&deployApp.password, "password", "P", "", "Password of private container registry")
-	appCmdDeploy.Flags().StringArrayVarP(&deployApp.env, "env", "e", nil, "Environment variable to set, as key=value pair")
+	appCmdDeploy.Flags().StringVar(&deployApp.registry, "registry", "", "Name of registry credentials added with appctl registry add, instead of --username and --password")
+	appCmdDeploy.Flags().StringArrayVarP(&deployApp.env, "env", "e", nil, "Environment variable to set, as key=value pair. Values like @file:path, @env:NAME, @cmd:command or @vault:path#field are read from secret providers")
 	appCmdDeploy.Flags().StringVarP(&deployApp.envFilePath, "envPath", "f", "", "Path to the environment variables file. Values in the .env file should be formatted as a line separated Key=Value pair")
 	appCmdDeploy.Flags().StringVarP(&deployApp.port, "port", "p", "", "The port where app server listens, set as '--port <port>'")
+	appCmdDeploy.Flags().BoolVar(&deployApp.skipImageCheck, "skip-image-check", false, "Deploy without checking the image in its registry first")
+	appCmdDeploy.Flags().BoolVar(&deployApp.keepTag, "keep-tag", false, "Deploy the image tag as given, instead of the digest the tag points to now")
+	appCmdDeploy.Flags().BoolVar(&deployApp.followTag, "follow-tag", false, "Keep running after the deploy, and deploy again each time the image tag points at a new digest, until interrupted. Each rollout deletes the app and creates it again, so it is down until the new revision starts")
+	appCmdDeploy.Flags().DurationVar(&deployApp.pollInterval, "poll-interval", constants.FOLLOWTAGINTERVAL*time.Second, "How often to check the image tag with --follow-tag")
+	appCmdDeploy.Flags().BoolVar(&deployApp.verifySignature, "verify-signature", false, "Refuse to deploy the image unless it has a cosign signature verified by --key")
+	appCmdDeploy.Flags().StringVar(&deployApp.keyPath, "key", "", "Path to the cosign public key to verify the image signature with, like cosign.pub")
+	appCmdDeploy.Flags().StringArrayVar(&deployApp.labels, "label", nil, "Label of the app, as key=value pair, repeat for each label")
+	appCmdDeploy.Flags().StringVar(&deployApp.policyPath, "policy", "", "Deployment policy file to check the app against (default $APPCTL_POLICY, or policy.yaml in the config directory if it exists)")
+	appCmdDeploy.Flags().BoolVar(&deployApp.resolveSecrets, "resolve-secrets", false, "Resolve secret references like @file:path in the env file too, its values are literal otherwise. -e values are always resolved")
+	appCmdDeploy.Flags().BoolVar(&deployApp.showSecrets, "show-secrets", false, "Show the values of environment variables that look like secrets in errors, instead of masking them")
+	appCmdDeploy.Flags().StringVar(&deployApp.protocol, "protocol", "", "Protocol of the port, http1 or h2c for HTTP/2 and gRPC apps (default http1)")
+	appCmdDeploy.Flags().StringVar(&deployApp.command, "command", "", "Executable to run in the container instead of the image entrypoint, its arguments are set with --arg")
+	appCmdDeploy.Flags().StringArrayVar(&deployApp.args, "arg", nil, "Argument of the command, or of the image entrypoint, repeat for each argument")
+	appCmdDeploy.Flags().StringVar(&deployApp.cpu, "cpu", "", "CPU requested for the app, in cores like 0.5 or millicores like 500m")
+	appCmdDeploy.Flags().StringVar(&deployApp.memory, "memory", "", "Memory requested for the app, like 512Mi or 1Gi")
+	appCmdDeploy.Flags().StringVar(&deployApp.cpuLimit, "cpu-limit", "", "Maximum CPU the app can use, in cores like 1 or millicores like 1500m")
+	appCmdDeploy.Flags().StringVar(&deployApp.memoryLimit, "memory-limit", "", "Maximum memory the app can use, like 1Gi")
+	appCmdDeploy.Flags().IntVar(&deployApp.minScale, "min-scale", 0, "Minimum number of replicas, 1 or more to avoid cold starts (default server's, scale to zero)")
+	appCmdDeploy.Flags().IntVar(&deployApp.maxScale, "max-scale", 0, "Maximum number of replicas, 0 for no limit (default server's)")
+	appCmdDeploy.Flags().IntVar(&deployApp.concurrencyTarget, "concurrency-target", 0, "Concurrent requests per replica the autoscaler aims for (default server's)")
+	appCmdDeploy.Flags().IntVar(&deployApp.containerConcurrency, "container-concurrency", 0, "Maximum concurrent requests per replica, 0 for no limit (default server's)")
+	appCmdDeploy.Flags().StringVar(&deployApp.scaleDownDelay, "scale-down-delay", "", "How long to wait before scaling down, up to 1h like 15m")
+	appCmdDeploy.Flags().StringVar(&deployApp.readinessPath, "readiness-path", "", "HTTP path the app answers once it can take requests, like /ready")
+	appCmdDeploy.Flags().StringVar(&deployApp.livenessPath, "liveness-path", "", "HTTP path the app answers while it isn't stuck, it is restarted otherwise, like /healthz")
+	appCmdDeploy.Flags().IntVar(&deployApp.probePeriod, "probe-period", 0, "Seconds between probes (default server's)")
+	appCmdDeploy.Flags().IntVar(&deployApp.probeTimeout, "probe-timeout", 0, "Seconds to wait for a probe to be answered (default server's)")
 }
 
-func appCmdDeployRun(cmd *cobra.Command, args []string) {
+func appCmdDeployRun(cmd *cobra.Command, args []string) error {
 	reader := bufio.NewReader(os.Stdin)
+	prompt := promptsEnabled()
+
+	// Validate the resources before asking for anything.
+	resources, err := appManageAPI.NewResources(deployApp.cpu, deployApp.memory, deployApp.cpuLimit, deployApp.memoryLimit)
+	if err != nil {
+		return err
+	}
+	options := appAPIs.ContainerOptions{Resources: resources, Registry: deployApp.registry}
+	if deployApp.registry != "" && (deployApp.userName != "" || deployApp.password != "") {
+		return fmt.Errorf("--registry can't be combined with --username or --password")
+	}
+	// The credentials of stored registries stay on the server, the tag
+	// couldn't be polled.
+	if deployApp.followTag && deployApp.registry != "" {
+		return fmt.Errorf("--follow-tag can't be combined with --registry, give the registry credentials with --username and --password")
+	}
+	if deployApp.followTag && deployApp.pollInterval < time.Second {
+		return fmt.Errorf("--poll-interval should be at least 1s")
+	}
+	// Load the key first, to not prompt for anything if it is invalid.
+	var signatureKey *ecdsa.PublicKey
+	if deployApp.verifySignature != (deployApp.keyPath != "") {
+		return fmt.Errorf("--verify-signature and --key should be specified together")
+	}
+	if deployApp.verifySignature {
+		if deployApp.keepTag {
+			return fmt.Errorf("--keep-tag can't be combined with --verify-signature, the digest verified is deployed")
+		}
+		key, errapi := appManageAPI.LoadSignatureKey(deployApp.keyPath)
+		if errapi != nil {
+			return errapi
+		}
+		signatureKey = key
+	}
+	if err := appManageAPI.SetLabels(&options, deployApp.labels); err != nil {
+		return err
+	}
+	if err := appManageAPI.SetProtocol(&options, deployApp.protocol); err != nil {
+		return err
+	}
+	if err := appManageAPI.SetCommand(&options, deployApp.command, deployApp.args); err != nil {
+		return err
+	}
+	// Validate the autoscaling, only the flags that are set are sent.
+	var autoscaling appManageAPI.Autoscaling
+	for flag, value := range map[string]**int{
+		"min-scale":             &autoscaling.MinScale,
+		"max-scale":             &autoscaling.MaxScale,
+		"concurrency-target":    &autoscaling.ConcurrencyTarget,
+		"container-concurrency": &autoscaling.ContainerConcurrency,
+	} {
+		if cmd.Flags().Changed(flag) {
+			count, _ := cmd.Flags().GetInt(flag)
+			*value = &count
+		}
+	}
+	autoscaling.ScaleDownDelay = deployApp.scaleDownDelay
+	if err := appManageAPI.SetAutoscaling(&options, autoscaling); err != nil {
+		return err
+	}
+	probes := appManageAPI.Probes{ReadinessPath: deployApp.readinessPath, LivenessPath: deployApp.livenessPath}
+	if cmd.Flags().Changed("probe-period") {
+		probes.PeriodSeconds = &deployApp.probePeriod
+	}
+	if cmd.Flags().Changed("probe-timeout") {
+		probes.TimeoutSeconds = &deployApp.probeTimeout
+	}
+	if err := appManageAPI.SetProbes(&options, probes); err != nil {
+		return err
+	}
+
+	// Without prompts, the app name and image must be given as flags.
+	if !prompt {
+		if deployApp.name == "" {
+			return missingFlagError("app-name")
+		}
+		if deployApp.image == "" {
+			return missingFlagError("image")
+		}
+	}
 
 	if deployApp.name == "" {
 		fmt.Printf("App Name: ")
@@ -104,9 +301,7 @@ func appCmdDeployRun(cmd *cobra.Command, args []string) {
 
 	// Validate app name.
 	if !constants.RegexValidate(deployApp.name, constants.ValidAppNameRegex) {
-		fmt.Printf("Invalid app name.\n")
-		fmt.Printf("Name of the app to be deployed must contain a lowercase alphanumeric characters, '-' or '.'\nand must start with alphanumeric characters only.\n")
-		return
+		return fmt.Errorf("invalid app name: %v, it must contain lowercase alphanumeric characters, '-' or '.', and start with an alphanumeric character", deployApp.name)
 	}
 
 	if deployApp.image == "" {
@@ -118,7 +313,13 @@ func appCmdDeployRun(cmd *cobra.Command, args []string) {
 
 	var isPrivateReg bool = true
 
-	if deployApp.userName == "" && deployApp.password == "" {
+	if deployApp.registry != "" {
+		// The registry credentials are stored on the server.
+		isPrivateReg = false
+	} else if deployApp.userName == "" && deployApp.password == "" && !prompt {
+		// Without prompts, the image is from a public registry unless credentials are given.
+		isPrivateReg = false
+	} else if deployApp.userName == "" && deployApp.password == "" {
 		fmt.Printf("Is the image from a private registry (Y/n)? [n]: ")
 		readerChar := bufio.NewReader(os.Stdin)
 		char, _, _ := readerChar.ReadRune()
@@ -140,35 +341,115 @@ func appCmdDeployRun(cmd *cobra.Command, args []string) {
 	}
 
 	//App to be deployed from private registry. Check if required options are provided
-	if isPrivateReg {
-		if deployApp.userName != "" && deployApp.password != "" {
-			//Continue in this case
-		} else {
-			//incorrect options specified. Either both or none of the Username and Password should be specified.
-			fmt.Printf("Incorrect options specified. Either both or none of the Username and Password should be specified.\n")
-			os.Exit(0)
+	if isPrivateReg && (deployApp.userName == "" || deployApp.password == "") {
+		return fmt.Errorf("either both or none of --username and --password should be specified")
+	}
+
+	// Check the image before deploying, the server takes a while to report a bad one.
+	if deployApp.followTag && strings.Contains(deployApp.image, "@") {
+		return fmt.Errorf("--follow-tag needs an image tag, not a digest")
+	}
+
+	var imagePort, digest string
+	image := deployApp.image
+	if !deployApp.skipImageCheck {
+		info, err := appManageAPI.PreflightImage(deployApp.image, deployApp.userName, deployApp.password, deployApp.registry != "")
+		if err != nil {
+			return err
+		}
+		if info != nil {
+			imagePort = info.SinglePort()
+			digest = info.Digest
+		}
+		// Pin the tag to its digest, so the app keeps running this build
+		// if the tag is pushed over.
+		if info != nil && !deployApp.keepTag {
+			image = appManageAPI.PinImage(&options, deployApp.image, info.Digest)
+			if image != deployApp.image {
+				fmt.Printf("Resolved %v to %v.\n", deployApp.image, info.Digest)
+			}
 		}
 	}
 
-	if deployApp.port == "" {
-		fmt.Printf("Port [8080]: ")
+	// Deploy the digest verified, the tag could be pushed over since.
+	if signatureKey != nil {
+		verified, errapi := appManageAPI.VerifyImageSignature(deployApp.image, digest, signatureKey, deployApp.userName, deployApp.password)
+		if errapi != nil {
+			return errapi
+		}
+		digest = verified
+		image = appManageAPI.PinImage(&options, deployApp.image, digest)
+	}
+
+	// The port defaults to the one the image exposes, if it exposes one,
+	// otherwise to 8080 on the server.
+	if deployApp.port == "" && prompt {
+		defaultPort := imagePort
+		if defaultPort == "" {
+			defaultPort = "8080"
+		}
+		fmt.Printf("Port [%v]: ", defaultPort)
 		port, _ := reader.ReadString('\n')
 		deployApp.port = strings.TrimSuffix(port, "\n")
 		deployApp.port = strings.TrimSuffix(deployApp.port, "\r")
 	}
+	if deployApp.port == "" && imagePort != "" {
+		deployApp.port = imagePort
+		fmt.Printf("Using port %v exposed by the image.\n", imagePort)
+	}
 
 	if deployApp.port != "" {
 		// Check if port given is valid i.e numeric only.
 		_, err := strconv.Atoi(deployApp.port)
 		if err != nil {
-			fmt.Printf("Invalid port. Please enter a valid port\n")
-			return
+			return fmt.Errorf("invalid port: %v, it must be a number", deployApp.port)
 		}
 	}
 
-	errapi := appManageAPI.CreateApp(deployApp.name, deployApp.image, deployApp.userName,
-		deployApp.password, deployApp.env, deployApp.envFilePath, deployApp.port)
+	// Resolve the env values referencing secrets once, the app and its
+	// redeploys get the values.
+	env, errapi := appManageAPI.ResolveEnv(deployApp.env, deployApp.envFilePath, deployApp.resolveSecrets)
+	if errapi != nil {
+		return errapi
+	}
+
+	// Check the app against the deployment policy, before sending anything.
+	request, errapi := appManageAPI.PolicyRequest(deployApp.name, deployApp.image, env, deployApp.port, options.Labels)
+	if errapi == nil {
+		appManageAPI.WarnSecrets(request.Env, deployApp.password)
+		errapi = appManageAPI.CheckPolicy(deployApp.policyPath, request)
+	}
+	if errapi != nil {
+		return errapi
+	}
+
+	errapi = appManageAPI.CreateApp(deployApp.name, image, deployApp.userName,
+		deployApp.password, env, "", deployApp.port, options)
 	if errapi != nil {
-		fmt.Printf("\nNot able to deploy app: %v.\nError: %v", deployApp.name, errapi)
+		// Server errors can include the request, with the secrets.
+		message := errapi.Error()
+		if !deployApp.showSecrets {
+			message = appManageAPI.MaskSecrets(message)
+		}
+		return fmt.Errorf("Not able to deploy app: %v.\nError: %v", deployApp.name, message)
+	}
+
+	if deployApp.followTag {
+		// Stop following on Ctrl+C, once a rollout in progress is done.
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		deployment := appManageAPI.Deployment{
+			Name:         deployApp.name,
+			Image:        deployApp.image,
+			Username:     deployApp.userName,
+			Password:     deployApp.password,
+			Env:          env,
+			Port:         deployApp.port,
+			Options:      options,
+			KeepTag:      deployApp.keepTag,
+			SignatureKey: signatureKey,
+		}
+		return appManageAPI.FollowTag(ctx, deployment, digest, deployApp.pollInterval)
 	}
+	return nil
 }
@@ -12,6 +12,12 @@ import (
 var describeExample = `
   # Get detailed information about an app deployed through app-name in json format.
   appctl describe -n <appname>
+
+  # Get a summary of an app, with the effective resources of its container.
+  appctl describe -n <appname> -o text
+
+  # Show the values of environment variables that look like secrets, masked otherwise.
+  appctl describe -n <appname> --show-secrets
  `
 
 // appCmdDescribe -- To describe an app running.
@@ -21,34 +27,42 @@ var (
 		Short:   "Provide detailed app information in json format",
 		Example: describeExample,
 		Long:    `Provide detailed app information in json format`,
-		Run:     appCmdDescribeRun,
+		RunE:    appCmdDescribeRun,
 	}
 )
 
 // command variables
-var appNameDescribe string
+var (
+	appNameDescribe string
+	// Output format, json or text.
+	describeOutput string
+	// To show the values of env variables that look like secrets.
+	describeShowSecrets bool
+)
 
 func init() {
 	rootCmd.AddCommand(appCmdDescribe)
 	appCmdDescribe.Flags().StringVarP(&appNameDescribe, "app-name", "n", "", "Name of app to be described")
+	appCmdDescribe.RegisterFlagCompletionFunc("app-name", completeAppNames)
+	appCmdDescribe.Flags().StringVarP(&describeOutput, "output", "o", appManageAPI.DescribeJSON, "Output format, json or text")
+	appCmdDescribe.Flags().BoolVar(&describeShowSecrets, "show-secrets", false, "Show the values of environment variables that look like secrets, instead of masking them")
 }
 
 // To get app information by its name
-func appCmdDescribeRun(cmd *cobra.Command, args []string) {
+func appCmdDescribeRun(cmd *cobra.Command, args []string) error {
 	// Check if App name provided.
 	if appNameDescribe == "" {
-		fmt.Printf("App name not specified.\n")
-		return
+		return fmt.Errorf("app name not specified, give it with --app-name")
 	}
 
 	// Validate app name.
 	if !constants.RegexValidate(appNameDescribe, constants.ValidAppNameRegex) {
-		fmt.Printf("Invalid app name.\n")
-		return
+		return fmt.Errorf("invalid app name: %v", appNameDescribe)
 	}
 
-	errapi := appManageAPI.GetAppByNameInfo(appNameDescribe)
-	if errapi != nil {
-		fmt.Printf("%v", errapi)
+	if describeOutput != appManageAPI.DescribeJSON && describeOutput != appManageAPI.DescribeText {
+		return fmt.Errorf("invalid output format %q, use json or text", describeOutput)
 	}
+
+	return appManageAPI.GetAppByNameInfo(appNameDescribe, describeOutput, describeShowSecrets)
 }
@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"fmt"
-
 	"github.com/platform9/appctl/pkg/appManageAPI"
 	"github.com/spf13/cobra"
 )
@@ -20,7 +18,7 @@ var (
 		Short:   "Show all the running apps",
 		Example: listExample,
 		Long:    `Show all the running apps`,
-		Run:     appCmdListRun,
+		RunE:    appCmdListRun,
 	}
 )
 
@@ -29,9 +27,6 @@ func init() {
 }
 
 // To list apps running in given namespace.
-func appCmdListRun(cmd *cobra.Command, args []string) {
-	errapi := appManageAPI.ListAppsInfo()
-	if errapi != nil {
-		fmt.Printf("%v", errapi)
-	}
+func appCmdListRun(cmd *cobra.Command, args []string) error {
+	return appManageAPI.ListAppsInfo()
 }
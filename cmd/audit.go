@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/platform9/appctl/pkg/appManageAPI"
+	"github.com/platform9/appctl/pkg/audit"
+	"github.com/platform9/appctl/pkg/settings"
+	"github.com/ryanuber/columnize"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// usage example
+var auditExample = `
+  # Start recording deploys, deletes and other operations in the audit log.
+  appctl audit enable
+
+  # Show all recorded operations.
+  appctl audit
+
+  # Show the operations on an app in the last week.
+  appctl audit --app <appname> --since 7d
+
+  # Show the failed operations on a day, as JSON.
+  appctl audit --since 2022-03-01 --until 2022-03-02 --outcome failure -o json
+ `
+
+// auditCmd represents "Query the audit log of appctl operations".
+var (
+	auditCmd = &cobra.Command{
+		Use:     "audit",
+		Short:   "Query the audit log of appctl operations",
+		Example: auditExample,
+		Long: `Query the audit log of appctl operations. The audit log is off by default,
+once enabled every operation is appended to ` + "`~/.config/pf9/audit.log`" + ` with the
+user, command, flags (secrets redacted), app and outcome.`,
+		Args: cobra.NoArgs,
+		RunE: auditCmdRun,
+	}
+
+	auditEnableCmd = &cobra.Command{
+		Use:   "enable",
+		Short: "Record operations in the audit log",
+		Args:  cobra.NoArgs,
+		RunE:  auditSetRun(true),
+	}
+
+	auditDisableCmd = &cobra.Command{
+		Use:   "disable",
+		Short: "Stop recording operations in the audit log, keeping the recorded ones",
+		Args:  cobra.NoArgs,
+		RunE:  auditSetRun(false),
+	}
+)
+
+// command variables
+var auditQuery struct {
+	app     string
+	since   string
+	until   string
+	outcome string
+	output  string
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.AddCommand(auditEnableCmd, auditDisableCmd)
+	auditCmd.Flags().StringVar(&auditQuery.app, "app", "", "Only show operations on this app")
+	auditCmd.Flags().StringVar(&auditQuery.since, "since", "", "Only show operations since a time, like 7d, 36h, 2022-03-01 or an RFC3339 timestamp")
+	auditCmd.Flags().StringVar(&auditQuery.until, "until", "", "Only show operations until a time, same formats as --since")
+	auditCmd.Flags().StringVar(&auditQuery.outcome, "outcome", "", "Only show operations with this outcome, success or failure")
+	auditCmd.Flags().StringVarP(&auditQuery.output, "output", "o", "table", "Output format, table or json")
+}
+
+// To query the audit log.
+func auditCmdRun(cmd *cobra.Command, args []string) error {
+	query := audit.Query{App: auditQuery.app, Outcome: auditQuery.outcome}
+	if query.Outcome != "" && query.Outcome != audit.Success && query.Outcome != audit.Failure {
+		return fmt.Errorf("Invalid outcome %q, use %s or %s.", query.Outcome, audit.Success, audit.Failure)
+	}
+	if auditQuery.output != "table" && auditQuery.output != "json" {
+		return fmt.Errorf("Invalid output format %q, use table or json.", auditQuery.output)
+	}
+	var err error
+	if query.Since, err = parseAuditTime(auditQuery.since); err != nil {
+		return err
+	}
+	if query.Until, err = parseAuditTime(auditQuery.until); err != nil {
+		return err
+	}
+
+	entries, err := audit.Read(query)
+	if err != nil {
+		return err
+	}
+
+	if auditQuery.output == "json" {
+		if entries == nil {
+			entries = []audit.Entry{}
+		}
+		data, err := json.MarshalIndent(entries, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(entries) == 0 {
+		if !audit.Enabled() {
+			fmt.Printf("No operations recorded. The audit log is off, enable it with `appctl audit enable`.\n")
+		} else {
+			fmt.Printf("No operations recorded.\n")
+		}
+		return nil
+	}
+	output := []string{"TIME | USER | COMMAND | APP | EVENT | OUTCOME"}
+	for _, entry := range entries {
+		output = append(output, fmt.Sprintf("%v | %v | %v | %v | %v | %v",
+			entry.Time.Local().Format(time.RFC3339), entry.User, entry.Command, entry.App, entry.Event, entry.Outcome))
+	}
+	fmt.Println(columnize.SimpleFormat(output))
+	return nil
+}
+
+// To turn the audit log on or off.
+func auditSetRun(enabled bool) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		saved, err := settings.Load()
+		if err != nil {
+			return err
+		}
+		saved.Audit = enabled
+		if err := saved.Save(); err != nil {
+			return fmt.Errorf("Failed to save audit setting with error: %v", err)
+		}
+		if enabled {
+			fmt.Printf("Audit log enabled, operations are recorded in %v\n", audit.Path())
+		} else {
+			fmt.Printf("Audit log disabled.\n")
+		}
+		return nil
+	}
+}
+
+// To parse --since and --until, as an age before now, a date or a timestamp.
+func parseAuditTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if age, err := appManageAPI.ParseAge(value); err == nil {
+		return time.Now().Add(-age), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("Invalid time %q, use an age like 7d or 36h, a date like 2022-03-01 or an RFC3339 timestamp.", value)
+}
+
+// To set the command line recorded in the audit log, with secrets redacted.
+func setAuditInvocation(cmd *cobra.Command, args []string) {
+	flags := map[string]string{}
+	var app string
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		values := []string{flag.Value.String()}
+		if slice, ok := flag.Value.(pflag.SliceValue); ok {
+			values = slice.GetSlice()
+		}
+		flags[flag.Name] = audit.RedactFlag(flag.Name, values)
+		if flag.Name == "app-name" {
+			app = flag.Value.String()
+		}
+	})
+	audit.SetInvocation(audit.Invocation{Command: cmd.CommandPath(), Args: args, Flags: flags, App: app})
+}
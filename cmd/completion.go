@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/platform9/appctl/pkg/appManageAPI"
+	"github.com/spf13/cobra"
+)
+
+// To complete app names, for the app-name flag and app name arguments.
+// Names already given as arguments aren't suggested again.
+func completeAppNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	names, err := appManageAPI.AppNames()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	given := map[string]bool{}
+	for _, arg := range args {
+		given[arg] = true
+	}
+	var completions []string
+	for _, name := range names {
+		if strings.HasPrefix(name, toComplete) && !given[name] {
+			completions = append(completions, name)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// To check if the command is run by shell completion, which must not print
+// anything but completions.
+func isCompletionCmd(cmd *cobra.Command) bool {
+	if cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd {
+		return true
+	}
+	return cmd.Name() == "completion" || (cmd.HasParent() && cmd.Parent().Name() == "completion")
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/platform9/appctl/pkg/appManageAPI"
+	"github.com/platform9/appctl/pkg/constants"
+	"github.com/spf13/cobra"
+)
+
+// usage example
+var curlExample = `
+  # Send a GET request to an app and print the response.
+  appctl curl -n <appname>
+  appctl curl -n <appname> /healthz
+
+  # Check the health of a gRPC app deployed with --protocol h2c, with grpc.health.v1.
+  appctl curl -n <appname> --grpc-health
+
+  # Check the health of one service of a gRPC app.
+  appctl curl -n <appname> --grpc-health --service helloworld.Greeter
+ `
+
+// appCmdCurl - To call a deployed app.
+var (
+	appCmdCurl = &cobra.Command{
+		Use:     "curl [path]",
+		Short:   "Call a deployed app, or check the health of a gRPC app",
+		Example: curlExample,
+		Long: `Send a GET request to a path of a deployed app and print the response.
+With --grpc-health, call the standard gRPC health check of the app instead,
+and fail unless it is serving.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: appCmdCurlRun,
+	}
+)
+
+// command variables
+var (
+	// App name to call.
+	curlAppName string
+	// To check gRPC health instead of sending a GET request.
+	curlGRPCHealth bool
+	// gRPC service to check, the whole server if empty.
+	curlService string
+)
+
+func init() {
+	rootCmd.AddCommand(appCmdCurl)
+	appCmdCurl.Flags().StringVarP(&curlAppName, "app-name", "n", "", "Name of the app to call")
+	appCmdCurl.RegisterFlagCompletionFunc("app-name", completeAppNames)
+	appCmdCurl.Flags().BoolVar(&curlGRPCHealth, "grpc-health", false, "Call grpc.health.v1.Health/Check on the app, which must be deployed with --protocol h2c")
+	appCmdCurl.Flags().StringVar(&curlService, "service", "", "gRPC service to check with --grpc-health (default the whole server)")
+}
+
+// To call an app, or check its gRPC health.
+func appCmdCurlRun(cmd *cobra.Command, args []string) error {
+	if curlAppName == "" {
+		return fmt.Errorf("--app-name is required")
+	}
+	if !constants.RegexValidate(curlAppName, constants.ValidAppNameRegex) {
+		return fmt.Errorf("invalid app name: %v", curlAppName)
+	}
+	if curlService != "" && !curlGRPCHealth {
+		return fmt.Errorf("--service can only be used with --grpc-health")
+	}
+
+	if curlGRPCHealth {
+		if len(args) > 0 {
+			return fmt.Errorf("a path can't be combined with --grpc-health")
+		}
+		// Fail, so scripts can tell the app isn't healthy.
+		return appManageAPI.CheckGRPCHealth(curlAppName, curlService)
+	}
+
+	path := "/"
+	if len(args) > 0 {
+		path = args[0]
+	}
+	return appManageAPI.CurlApp(curlAppName, path)
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/platform9/appctl/pkg/appManageAPI"
+	"github.com/platform9/appctl/pkg/constants"
+	"github.com/spf13/cobra"
+)
+
+// usage example
+var domainExample = `
+  # Serve an app on a custom domain, prints the DNS record to create and
+  # waits for the domain and its certificate to be ready.
+  appctl domain add -n <appname> app.example.com
+
+  # Add a domain without waiting for it to be ready.
+  appctl domain add -n <appname> app.example.com --no-wait
+
+  # List the custom domains of an app and their status.
+  appctl domain list -n <appname>
+
+  # Stop serving an app on a custom domain.
+  appctl domain remove -n <appname> app.example.com
+ `
+
+// domainCmd represents "Manage the custom domains of an app".
+var (
+	domainCmd = &cobra.Command{
+		Use:     "domain",
+		Short:   "Manage the custom domains of an app",
+		Example: domainExample,
+		Long: `Manage the custom domains of an app. Each domain needs a DNS record
+pointing to the app, and gets its own certificate once the record is created.`,
+	}
+
+	domainAddCmd = &cobra.Command{
+		Use:   "add DOMAIN",
+		Short: "Serve an app on a custom domain",
+		Args:  cobra.ExactArgs(1),
+		RunE:  domainAddRun,
+	}
+
+	domainListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the custom domains of an app",
+		Args:  cobra.NoArgs,
+		RunE:  domainListRun,
+	}
+
+	domainRemoveCmd = &cobra.Command{
+		Use:   "remove DOMAIN",
+		Short: "Stop serving an app on a custom domain",
+		Args:  cobra.ExactArgs(1),
+		RunE:  domainRemoveRun,
+	}
+)
+
+// command variables
+var (
+	// App name of the domains.
+	domainAppName string
+	// To return once the domain is added, without waiting for it.
+	domainNoWait bool
+)
+
+func init() {
+	rootCmd.AddCommand(domainCmd)
+	domainCmd.AddCommand(domainAddCmd, domainListCmd, domainRemoveCmd)
+	domainCmd.PersistentFlags().StringVarP(&domainAppName, "app-name", "n", "", "Name of the app")
+	domainCmd.RegisterFlagCompletionFunc("app-name", completeAppNames)
+	domainAddCmd.Flags().BoolVar(&domainNoWait, "no-wait", false, "Don't wait for the domain and its certificate to be ready")
+}
+
+// To add a custom domain to an app.
+func domainAddRun(cmd *cobra.Command, args []string) error {
+	if err := validateDomainAppName(); err != nil {
+		return err
+	}
+	domain, err := appManageAPI.ValidateDomain(args[0])
+	if err != nil {
+		return err
+	}
+	return appManageAPI.AddDomain(domainAppName, domain, !domainNoWait)
+}
+
+// To list the custom domains of an app.
+func domainListRun(cmd *cobra.Command, args []string) error {
+	if err := validateDomainAppName(); err != nil {
+		return err
+	}
+	return appManageAPI.ListDomains(domainAppName)
+}
+
+// To remove a custom domain of an app.
+func domainRemoveRun(cmd *cobra.Command, args []string) error {
+	if err := validateDomainAppName(); err != nil {
+		return err
+	}
+	domain, err := appManageAPI.ValidateDomain(args[0])
+	if err != nil {
+		return err
+	}
+	return appManageAPI.RemoveDomain(domainAppName, domain)
+}
+
+func validateDomainAppName() error {
+	if domainAppName == "" {
+		return fmt.Errorf("--app-name is required")
+	}
+	if !constants.RegexValidate(domainAppName, constants.ValidAppNameRegex) {
+		return fmt.Errorf("invalid app name: %v", domainAppName)
+	}
+	return nil
+}
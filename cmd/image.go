@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/platform9/appctl/pkg/appManageAPI"
+	"github.com/spf13/cobra"
+)
+
+// usage example
+var imageExample = `
+  # Show the ports, platforms, size, entrypoint and digest of an image.
+  appctl image inspect gcr.io/knative-samples/helloworld-go
+
+  # Inspect an image of a private registry.
+  appctl image inspect ghcr.io/<org>/<image>:<tag> -u <username> -P <password>
+
+  # Print the details as JSON.
+  appctl image inspect <image> -o json
+ `
+
+// imageCmd represents "Inspect container images".
+var (
+	imageCmd = &cobra.Command{
+		Use:     "image",
+		Short:   "Inspect container images",
+		Example: imageExample,
+	}
+
+	imageInspectCmd = &cobra.Command{
+		Use:   "inspect IMAGE",
+		Short: "Show the ports, platforms, size, entrypoint and digest of an image",
+		Long: `Show the ports, platforms, size, entrypoint and digest of an image, read
+from its registry without pulling it. The size is the compressed size of
+the image of each platform, as pulled.`,
+		Args: cobra.ExactArgs(1),
+		RunE: imageInspectRun,
+	}
+)
+
+// command variables
+var (
+	// Credentials of the registry of the image.
+	imageUsername string
+	imagePassword string
+	// Output format, json or text.
+	imageOutput string
+)
+
+func init() {
+	rootCmd.AddCommand(imageCmd)
+	imageCmd.AddCommand(imageInspectCmd)
+	imageInspectCmd.Flags().StringVarP(&imageUsername, "username", "u", "", "Username of private container registry")
+	imageInspectCmd.Flags().StringVarP(&imagePassword, "password", "P", "", "Password of private container registry")
+	imageInspectCmd.Flags().StringVarP(&imageOutput, "output", "o", appManageAPI.DescribeText, "Output format, json or text")
+}
+
+// To inspect an image in its registry.
+func imageInspectRun(cmd *cobra.Command, args []string) error {
+	if imageOutput != appManageAPI.DescribeJSON && imageOutput != appManageAPI.DescribeText {
+		return fmt.Errorf("invalid output format %q, use json or text", imageOutput)
+	}
+	if (imageUsername == "") != (imagePassword == "") {
+		return fmt.Errorf("either both or none of --username and --password should be specified")
+	}
+	return appManageAPI.InspectImage(args[0], imageUsername, imagePassword, imageOutput)
+}
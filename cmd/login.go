@@ -1,9 +1,10 @@
 package cmd
 
 import (
-	"fmt"
+	"os"
 
 	"github.com/platform9/appctl/pkg/appManageAPI"
+	"github.com/platform9/appctl/pkg/constants"
 	"github.com/spf13/cobra"
 )
 
@@ -11,6 +12,20 @@ import (
 var loginExample = `
   # Login using Google account/Github account to use appctl.
   appctl login
+
+  # Login in the browser on this machine, without confirming a device code.
+  appctl login --web
+
+  # Login from a machine without a browser, such as over SSH.
+  # Prints the login URL and a QR code to open it from another device.
+  appctl login --no-browser
+
+  # Login from CI using the client credentials of an auth0 machine to machine application.
+  # The secret can also be set in the APPCTL_CLIENT_SECRET environment variable.
+  appctl login --client-id <client id> --client-secret <client secret>
+
+  # Or skip login, and use a token from the APPCTL_TOKEN environment variable.
+  APPCTL_TOKEN=<token> appctl list
  `
 
 // loginCmd represents "Login and use appctl".
@@ -20,18 +35,41 @@ var (
 		Short:   "Login using Google account/Github account to use appctl",
 		Example: loginExample,
 		Long:    `Login using Google account/Github account to use appctl`,
-		Run:     loginCmdRun,
+		RunE:    loginCmdRun,
 	}
 )
 
+// command variables
+var (
+	// To print the login URL instead of opening a browser.
+	noBrowser bool
+	// To login with a redirect to localhost instead of the device code.
+	webLogin bool
+	// Client credentials for machine to machine login.
+	clientID     string
+	clientSecret string
+)
+
 func init() {
 	rootCmd.AddCommand(loginCmd)
+	loginCmd.Flags().BoolVar(&noBrowser, "no-browser", false, "Print the login URL and a QR code instead of opening a browser")
+	loginCmd.Flags().BoolVar(&webLogin, "web", false, "Login in the browser with a redirect to localhost, falls back to device login if that isn't possible")
+	loginCmd.Flags().StringVar(&clientID, "client-id", "", "Client ID to login with the client credentials grant, for CI")
+	loginCmd.Flags().StringVar(&clientSecret, "client-secret", "", "Client secret to login with the client credentials grant (default $APPCTL_CLIENT_SECRET)")
 }
 
 // To login.
-func loginCmdRun(cmd *cobra.Command, args []string) {
-	errapi := appManageAPI.LoginApp()
-	if errapi != nil {
-		fmt.Printf("%v", errapi)
+func loginCmdRun(cmd *cobra.Command, args []string) error {
+	if clientID != "" {
+		if clientSecret == "" {
+			clientSecret = os.Getenv(constants.CLIENTSECRETENVVAR)
+		}
+		return appManageAPI.LoginClientCredentials(clientID, clientSecret)
 	}
+
+	if webLogin && !noBrowser {
+		return appManageAPI.LoginAppWeb()
+	}
+
+	return appManageAPI.LoginApp(noBrowser)
 }
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"github.com/platform9/appctl/pkg/appManageAPI"
+	"github.com/spf13/cobra"
+)
+
+// usage example
+var policyExample = `
+  # Check app manifests against the deployment policy, without deploying them.
+  appctl policy test apps.yaml
+
+  # Check them against another policy file.
+  appctl policy test -p policy.yaml apps.yaml worker.yaml
+ `
+
+// policyCmd represents "Check apps against the deployment policy".
+var (
+	policyCmd = &cobra.Command{
+		Use:     "policy",
+		Short:   "Check apps against the deployment policy",
+		Example: policyExample,
+		Long: `Check apps against the deployment policy. The policy is a YAML file of
+rules, like the registries images may come from and the labels apps must
+have. deploy checks every app against it before sending anything, and
+refuses to deploy apps that break a rule.`,
+	}
+
+	policyTestCmd = &cobra.Command{
+		Use:   "test MANIFEST...",
+		Short: "Check app manifests against the policy offline",
+		Long: `Check app manifests against the policy offline, and fail if any app
+breaks a rule. Manifests are YAML files of apps with a name, image, port,
+env and labels, several apps separated by ---.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: policyTestRun,
+	}
+)
+
+// command variables
+var (
+	// Policy file, the default one if empty.
+	policyPath string
+)
+
+func init() {
+	rootCmd.AddCommand(policyCmd)
+	policyCmd.AddCommand(policyTestCmd)
+	policyTestCmd.Flags().StringVarP(&policyPath, "policy", "p", "", "Deployment policy file (default $APPCTL_POLICY, or policy.yaml in the config directory)")
+}
+
+// To check manifests against the policy.
+func policyTestRun(cmd *cobra.Command, args []string) error {
+	// Fail, so CI jobs can tell an app breaks the policy.
+	return appManageAPI.PolicyTest(policyPath, args)
+}
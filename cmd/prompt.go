@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// To check if the user can be prompted for missing values. Prompts are
+// disabled with --non-interactive, or when stdin is not a terminal, as in
+// CI pipelines where reading stdin would hang or read garbage.
+func promptsEnabled() bool {
+	return !nonInteractive && terminal.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// Usage error for a value that would otherwise have been prompted for.
+func missingFlagError(flag string) error {
+	return fmt.Errorf("--%s is required when prompts are disabled (--non-interactive or stdin is not a terminal)", flag)
+}
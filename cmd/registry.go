@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/platform9/appctl/pkg/appAPIs"
+	"github.com/platform9/appctl/pkg/appManageAPI"
+	"github.com/platform9/appctl/pkg/constants"
+	"github.com/spf13/cobra"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// usage example
+var registryExample = `
+  # Add the credentials of a private registry once, reading the password from stdin.
+  echo "$GHCR_TOKEN" | appctl registry add ghcr --server ghcr.io -u <username> --password-stdin
+
+  # Deploy apps with them, instead of passing --username and --password each time.
+  appctl deploy -n <appname> -i ghcr.io/<org>/<image>:<tag> --registry ghcr
+
+  # List the registries and the apps using them.
+  appctl registry list
+
+  # Replace the password, for every app using the registry.
+  echo "$NEW_GHCR_TOKEN" | appctl registry rotate ghcr --password-stdin
+
+  # Delete a registry no app uses anymore.
+  appctl registry delete ghcr
+ `
+
+// registryCmd represents "Manage the credentials of private registries".
+var (
+	registryCmd = &cobra.Command{
+		Use:     "registry",
+		Short:   "Manage the credentials of private registries",
+		Example: registryExample,
+		Long: `Manage the credentials of private container registries. They are stored
+on the server as pull secrets, which apps reference by name with deploy --registry.`,
+	}
+
+	registryAddCmd = &cobra.Command{
+		Use:   "add NAME",
+		Short: "Add the credentials of a private registry",
+		Args:  cobra.ExactArgs(1),
+		RunE:  registryAddRun,
+	}
+
+	registryListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the registries and the apps using them",
+		Args:  cobra.NoArgs,
+		RunE:  registryListRun,
+	}
+
+	registryDeleteCmd = &cobra.Command{
+		Use:   "delete NAME",
+		Short: "Delete the credentials of a registry",
+		Args:  cobra.ExactArgs(1),
+		RunE:  registryDeleteRun,
+	}
+
+	registryRotateCmd = &cobra.Command{
+		Use:   "rotate NAME",
+		Short: "Replace the credentials of a registry, for every app using it",
+		Args:  cobra.ExactArgs(1),
+		RunE:  registryRotateRun,
+	}
+)
+
+// command variables
+var (
+	// Host of the registry.
+	registryServer string
+	// Username of the registry.
+	registryUsername string
+	// To read the password from stdin, instead of prompting for it.
+	registryPasswordStdin bool
+	// To delete a registry that apps still use.
+	registryForce bool
+)
+
+func init() {
+	rootCmd.AddCommand(registryCmd)
+	registryCmd.AddCommand(registryAddCmd, registryListCmd, registryDeleteCmd, registryRotateCmd)
+	registryAddCmd.Flags().StringVar(&registryServer, "server", "", "Host of the registry, like ghcr.io or registry.example.com:5000")
+	for _, cmd := range []*cobra.Command{registryAddCmd, registryRotateCmd} {
+		cmd.Flags().StringVarP(&registryUsername, "username", "u", "", "Username of the registry")
+		cmd.Flags().BoolVar(&registryPasswordStdin, "password-stdin", false, "Read the password or token from stdin")
+	}
+	registryDeleteCmd.Flags().BoolVarP(&registryForce, "force", "f", false, "Delete the registry even if apps use it")
+}
+
+// To add the credentials of a registry.
+func registryAddRun(cmd *cobra.Command, args []string) error {
+	if err := validateRegistryName(args[0]); err != nil {
+		return err
+	}
+	if registryServer == "" {
+		return fmt.Errorf("--server is required")
+	}
+	server, err := appManageAPI.ValidateRegistryServer(registryServer)
+	if err != nil {
+		return err
+	}
+	if registryUsername == "" {
+		return fmt.Errorf("--username is required")
+	}
+	password, err := readRegistryPassword()
+	if err != nil {
+		return err
+	}
+
+	registry := appAPIs.Registry{Name: args[0], Server: server, Username: registryUsername, Password: password}
+	return appManageAPI.AddRegistry(registry)
+}
+
+// To list the registries.
+func registryListRun(cmd *cobra.Command, args []string) error {
+	return appManageAPI.ListRegistries()
+}
+
+// To delete the credentials of a registry.
+func registryDeleteRun(cmd *cobra.Command, args []string) error {
+	if err := validateRegistryName(args[0]); err != nil {
+		return err
+	}
+	return appManageAPI.DeleteRegistry(args[0], registryForce)
+}
+
+// To replace the credentials of a registry.
+func registryRotateRun(cmd *cobra.Command, args []string) error {
+	if err := validateRegistryName(args[0]); err != nil {
+		return err
+	}
+	password, err := readRegistryPassword()
+	if err != nil {
+		return err
+	}
+
+	registry := appAPIs.Registry{Name: args[0], Username: registryUsername, Password: password}
+	return appManageAPI.RotateRegistry(registry)
+}
+
+func validateRegistryName(name string) error {
+	if !constants.RegexValidate(name, constants.ValidAppNameRegex) {
+		return fmt.Errorf("invalid registry name: %v, it must contain lowercase alphanumeric characters, '-' or '.'", name)
+	}
+	return nil
+}
+
+// To read the password from stdin with --password-stdin, or prompt for it.
+// Passwords aren't taken as flags, to keep them out of the shell history.
+func readRegistryPassword() (string, error) {
+	var password string
+	if registryPasswordStdin {
+		data, err := io.ReadAll(bufio.NewReader(os.Stdin))
+		if err != nil {
+			return "", fmt.Errorf("failed to read the password from stdin: %v", err)
+		}
+		password = strings.TrimRight(string(data), "\r\n")
+	} else if promptsEnabled() {
+		fmt.Printf("Password: ")
+		data, _ := terminal.ReadPassword(0)
+		fmt.Printf("\n")
+		password = string(data)
+	} else {
+		return "", missingFlagError("password-stdin")
+	}
+	if password == "" {
+		return "", fmt.Errorf("the password is empty")
+	}
+	return password, nil
+}
@@ -3,24 +3,39 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/platform9/appctl/pkg/appManageAPI"
 	"github.com/platform9/appctl/pkg/color"
 	"github.com/platform9/appctl/pkg/constants"
+	"github.com/platform9/appctl/pkg/segment"
 
 	"github.com/spf13/cobra"
-	"go.uber.org/zap"
 )
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
-	Use: "appctl",
+	Use:           "appctl",
+	SilenceUsage:  true,
+	SilenceErrors: true,
 	Long: `CLI to deploy & manage apps in Platform9 environment.
 Login first using "appctl login" to use available commands.`,
-	PersistentPreRun: ensureAppSecrets,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if isCompletionCmd(cmd) {
+			return
+		}
+		ensureAppSecrets(cmd, args)
+		showTelemetryNotice(cmd)
+		setAuditInvocation(cmd, args)
+	},
 }
 
+// command variables
+// To never prompt for input, for use in pipelines.
+var nonInteractive bool
+
 func ensureAppSecrets(cmd *cobra.Command, args []string) {
-	if cmd.Name() == "help" || cmd.Name() == "version" {
+	if cmd.Name() == "help" || cmd.Name() == "version" || isTelemetryCmd(cmd) || isAuditCmd(cmd) {
 		return
 	}
 	requiredSecrets := map[string]string{
@@ -43,17 +58,52 @@ func ensureAppSecrets(cmd *cobra.Command, args []string) {
 	}
 }
 
+// To show the first-run telemetry notice, before any event is sent.
+func showTelemetryNotice(cmd *cobra.Command) {
+	if cmd.Name() == "help" || cmd.Name() == "version" || isTelemetryCmd(cmd) {
+		return
+	}
+	segment.ShowTelemetryNotice(os.Stderr)
+}
+
+func isTelemetryCmd(cmd *cobra.Command) bool {
+	return cmd == telemetryCmd || cmd.Parent() == telemetryCmd
+}
+
+func isAuditCmd(cmd *cobra.Command) bool {
+	return cmd == auditCmd || cmd.Parent() == auditCmd
+}
+
+// To record the command in the audit log once it is done, with its outcome,
+// including the commands refused before reaching the server.
+func recordAuditEntry(cmd *cobra.Command, err error) {
+	if cmd == nil || cmd == rootCmd || isCompletionCmd(cmd) || isTelemetryCmd(cmd) || isAuditCmd(cmd) ||
+		cmd.Name() == "help" || cmd.Name() == "version" {
+		return
+	}
+	// Not set yet if the flags couldn't be parsed.
+	setAuditInvocation(cmd, cmd.Flags().Args())
+	appManageAPI.RecordCommand(strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name()+" "), err)
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		zap.S().Fatalf(err.Error())
+	cmd, err := rootCmd.ExecuteC()
+	recordAuditEntry(cmd, err)
+	// Send the usage events queued by the command.
+	appManageAPI.CloseTracker()
+	if err != nil {
+		// The error and a hint, not the whole usage, so it stands out in
+		// pipeline logs. Exits non zero, so pipelines fail.
+		fmt.Fprintf(os.Stderr, "Error: %v\n", strings.TrimRight(err.Error(), "\n"))
+		fmt.Fprintf(os.Stderr, "Run '%v --help' for usage.\n", cmd.CommandPath())
+		os.Exit(1)
 	}
 }
 
 func init() {
 	//cobra.OnInitialize(initConfig)
-	// To tell Cobra not to provide the default completion command.
-	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.PersistentFlags().BoolVar(&nonInteractive, "non-interactive", false, "Never prompt for input, fail if a required value is missing")
 	//rootCmd.PersistentFlags().BoolVar(&verbosity, "verbose", false, "print verbose logs to console")
 }
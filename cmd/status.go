@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/platform9/appctl/pkg/appManageAPI"
+	"github.com/platform9/appctl/pkg/constants"
+	"github.com/spf13/cobra"
+)
+
+// usage example
+var statusExample = `
+  # Show the image, digest and readiness of all apps.
+  appctl status
+
+  # Flag the apps whose tag now points at another digest than the one deployed.
+  appctl status --check-drift
+
+  # Check one app, with the credentials of its private registry.
+  appctl status -n <appname> --check-drift -u <username> -P <password>
+ `
+
+// appCmdStatus - To show the image and digest apps run.
+var (
+	appCmdStatus = &cobra.Command{
+		Use:     "status",
+		Short:   "Show the image and digest apps run, and if their tag moved",
+		Example: statusExample,
+		Long: `Show the image each app was deployed from, the digest it runs and if it
+is ready. With --check-drift, the tag of each app is resolved again in its
+registry, and the command fails if any tag now points at another digest.`,
+		Args: cobra.NoArgs,
+		RunE: appCmdStatusRun,
+	}
+)
+
+// command variables
+var (
+	// App name to show, all apps if empty.
+	statusAppName string
+	// To resolve the tags again and compare their digests.
+	statusCheckDrift bool
+	// Credentials of the registry of the images.
+	statusUsername string
+	statusPassword string
+)
+
+func init() {
+	rootCmd.AddCommand(appCmdStatus)
+	appCmdStatus.Flags().StringVarP(&statusAppName, "app-name", "n", "", "Name of the app to show (default all apps)")
+	appCmdStatus.RegisterFlagCompletionFunc("app-name", completeAppNames)
+	appCmdStatus.Flags().BoolVar(&statusCheckDrift, "check-drift", false, "Resolve the tag of each app again, and flag the apps whose tag points at another digest")
+	appCmdStatus.Flags().StringVarP(&statusUsername, "username", "u", "", "Username of private container registry, for --check-drift")
+	appCmdStatus.Flags().StringVarP(&statusPassword, "password", "P", "", "Password of private container registry, for --check-drift")
+}
+
+// To show the status of apps.
+func appCmdStatusRun(cmd *cobra.Command, args []string) error {
+	if statusAppName != "" && !constants.RegexValidate(statusAppName, constants.ValidAppNameRegex) {
+		return fmt.Errorf("invalid app name: %v", statusAppName)
+	}
+	if (statusUsername == "") != (statusPassword == "") {
+		return fmt.Errorf("either both or none of --username and --password should be specified")
+	}
+	if (statusUsername != "") && !statusCheckDrift {
+		return fmt.Errorf("--username and --password can only be used with --check-drift")
+	}
+	// Fail on drift, so scripts can tell apps are behind their tag.
+	return appManageAPI.AppStatus(statusAppName, statusCheckDrift, statusUsername, statusPassword)
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/platform9/appctl/pkg/segment"
+	"github.com/platform9/appctl/pkg/settings"
+	"github.com/spf13/cobra"
+)
+
+// usage example
+var telemetryExample = `
+  # Check if usage data is sent, and what decided it.
+  appctl telemetry status
+
+  # Stop sending usage data.
+  appctl telemetry disable
+
+  # Print the usage data to stderr instead of sending it.
+  appctl telemetry show
+
+  # Send usage data again.
+  appctl telemetry enable
+
+  # Or opt out through the environment, DO_NOT_TRACK=1 also works.
+  APPCTL_TELEMETRY=off appctl list
+ `
+
+// telemetryCmd represents "Manage the usage data sent by appctl".
+var (
+	telemetryCmd = &cobra.Command{
+		Use:     "telemetry",
+		Short:   "Manage the usage data sent by appctl",
+		Example: telemetryExample,
+		Long: `Manage the usage data sent by appctl. The DO_NOT_TRACK and
+APPCTL_TELEMETRY environment variables override the saved setting.`,
+	}
+
+	telemetryStatusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "Show if usage data is sent",
+		Args:  cobra.NoArgs,
+		RunE:  telemetryStatusRun,
+	}
+
+	telemetryEnableCmd = &cobra.Command{
+		Use:   "enable",
+		Short: "Send usage data",
+		Args:  cobra.NoArgs,
+		RunE:  telemetrySetRun(segment.TelemetryOn),
+	}
+
+	telemetryDisableCmd = &cobra.Command{
+		Use:   "disable",
+		Short: "Stop sending usage data",
+		Args:  cobra.NoArgs,
+		RunE:  telemetrySetRun(segment.TelemetryOff),
+	}
+
+	telemetryShowCmd = &cobra.Command{
+		Use:   "show",
+		Short: "Print the exact usage data payload to stderr instead of sending it",
+		Args:  cobra.NoArgs,
+		RunE:  telemetrySetRun(segment.TelemetryShow),
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(telemetryCmd)
+	telemetryCmd.AddCommand(telemetryStatusCmd, telemetryEnableCmd, telemetryDisableCmd, telemetryShowCmd)
+}
+
+// To print the telemetry mode.
+func telemetryStatusRun(cmd *cobra.Command, args []string) error {
+	mode, source := segment.TelemetryMode()
+	fmt.Printf("Telemetry: %s (%s)\n", mode, source)
+	return nil
+}
+
+// To save the telemetry mode.
+func telemetrySetRun(mode string) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		saved, err := settings.Load()
+		if err != nil {
+			return err
+		}
+		saved.Telemetry = mode
+		// Choosing a mode is acknowledging the notice.
+		saved.TelemetryNoticeShown = true
+		if err := saved.Save(); err != nil {
+			return fmt.Errorf("Failed to save telemetry setting with error: %v", err)
+		}
+
+		fmt.Printf("Telemetry set to %s.\n", mode)
+		if current, source := segment.TelemetryMode(); current != mode {
+			fmt.Printf("Note: telemetry is %s for now, as %s.\n", current, source)
+		}
+		return nil
+	}
+}
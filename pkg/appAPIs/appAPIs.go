@@ -4,8 +4,10 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	neturl "net/url"
 	"os"
 	"strings"
 
@@ -40,12 +42,9 @@ type TokenInfo struct {
 	ErrorDescription string `json:"error_description"`
 }
 
-// To fetch the information (list,create,device,token).
+// To fetch the information (device).
 var (
-	listAppsInfo  map[string]interface{}
-	getAppInfo    map[string]interface{}
 	getDeviceInfo DeviceInfo
-	getTokenInfo  TokenInfo
 )
 
 // API to list/get all apps.
@@ -91,6 +90,7 @@ func ListApps(token string) (map[string]interface{}, error) {
 		return nil, checkErrors(err)
 	}
 
+	var listAppsInfo map[string]interface{}
 	err = json.Unmarshal([]byte(list_apps), &listAppsInfo)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to unmarshal with error: %s", err)
@@ -131,7 +131,7 @@ func (cli_api *AppAPI) createAppAPI(createInfo string, token string) ([]byte, er
 
 // To get all the apps information.
 func CreateApp(name string, image string, username string, password string,
-	env []string, envFilePath string, port string, token string) error {
+	env []string, envFilePath string, port string, options ContainerOptions, token string) error {
 	// Endpoint to list apps.
 	url := fmt.Sprintf(constants.APPURL)
 	var createInfo string
@@ -191,9 +191,13 @@ func CreateApp(name string, image string, username string, password string,
 		if port != "" {
 			createInfo = baseString + fmt.Sprintf(`"port": "%s"}`, port)
 		} else {
-			createInfo = baseString
+			createInfo = strings.TrimSuffix(baseString, ", ") + "}"
 		}
 	}
+	createInfo, err := withContainerOptions(createInfo, options)
+	if err != nil {
+		return err
+	}
 	client := &http.Client{}
 
 	cli_api := AppAPI{client, url}
@@ -259,6 +263,8 @@ func GetAppByName(appName string, token string) (map[string]interface{}, error)
 		return nil, checkErrors(err)
 	}
 
+	// Decode in to a new map, apps may be fetched concurrently.
+	var getAppInfo map[string]interface{}
 	err = json.Unmarshal([]byte(get_app), &getAppInfo)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to parse the response. Error: %s", err)
@@ -354,11 +360,79 @@ func RequestToken(deviceCode string) (*TokenInfo, error) {
 		return nil, checkErrors(err)
 	}
 
-	err = json.Unmarshal([]byte(tokenInfo), &getTokenInfo)
+	// Decode in to a new TokenInfo, fields of earlier polls must not carry over.
+	var token TokenInfo
+	err = json.Unmarshal([]byte(tokenInfo), &token)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to unmarshal with error: %s", err)
 	}
-	return &getTokenInfo, nil
+	return &token, nil
+}
+
+// Exchange an authorization code for a token, with the PKCE code verifier.
+func RequestAuthorizationCodeToken(code string, codeVerifier string, redirectURI string) (*TokenInfo, error) {
+	// Endpoint to request for token.
+	url := fmt.Sprintf("https://%s/oauth/token", constants.DOMAIN)
+
+	form := neturl.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("client_id", constants.CLIENTID)
+	form.Set("code", code)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("redirect_uri", redirectURI)
+
+	client := &http.Client{}
+
+	cli_api := AppAPI{client, url}
+
+	tokenInfo, err := cli_api.requestTokenAPI(form.Encode())
+	if err != nil {
+		return nil, checkErrors(err)
+	}
+
+	var token TokenInfo
+	err = json.Unmarshal([]byte(tokenInfo), &token)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal with error: %s", err)
+	}
+	if token.Error != "" {
+		return &token, fmt.Errorf("%v: %v", token.Error, token.ErrorDescription)
+	}
+	return &token, nil
+}
+
+// Request for an access token using the OAuth client credentials grant,
+// for machine to machine login from CI.
+func RequestClientCredentialsToken(clientID string, clientSecret string) (*TokenInfo, error) {
+	// Endpoint to request for token.
+	url := fmt.Sprintf("https://%s/oauth/token", constants.DOMAIN)
+
+	form := neturl.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", clientID)
+	form.Set("client_secret", clientSecret)
+	if constants.AUDIENCE != "" {
+		form.Set("audience", constants.AUDIENCE)
+	}
+
+	client := &http.Client{}
+
+	cli_api := AppAPI{client, url}
+
+	tokenInfo, err := cli_api.requestTokenAPI(form.Encode())
+	if err != nil {
+		return nil, checkErrors(err)
+	}
+
+	var token TokenInfo
+	err = json.Unmarshal([]byte(tokenInfo), &token)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to unmarshal with error: %s", err)
+	}
+	if token.Error != "" {
+		return &token, fmt.Errorf("%v: %v", token.Error, token.ErrorDescription)
+	}
+	return &token, nil
 }
 
 // API to delete a particular app by name.
@@ -459,9 +533,9 @@ func genEnvSlice(env []string) ([]string, map[string]string) {
 	sliceMap := make(map[string]string)
 	if env != nil {
 		for _, value := range env {
-			splitEnv := strings.Split(value, "=")
-			sliceMap[splitEnv[0]] = splitEnv[1]
-			envSlice = append(envSlice, fmt.Sprintf(`{"key": "%v", "value": "%v"}`, splitEnv[0], splitEnv[1]))
+			splitEnv := strings.SplitN(value, "=", 2)
+			sliceMap[splitEnv[0]] = splitEnv[len(splitEnv)-1]
+			envSlice = append(envSlice, envEntry(splitEnv[0], splitEnv[len(splitEnv)-1]))
 		}
 	}
 	for count := 0; count < len(envSlice)-1; count++ {
@@ -470,6 +544,14 @@ func genEnvSlice(env []string) ([]string, map[string]string) {
 	return envSlice, sliceMap
 }
 
+// Environment variable as JSON, values like secrets can have quotes and
+// backslashes.
+func envEntry(key string, value string) string {
+	encodedKey, _ := json.Marshal(key)
+	encodedValue, _ := json.Marshal(value)
+	return fmt.Sprintf(`{"key": %s, "value": %s}`, encodedKey, encodedValue)
+}
+
 // Generate environemnt slice from env File. [{ "key":"ENV1", "value":"val1"}, { "key":"ENV2", "value":"val2"}]
 func GetSliceFromEnvFile(envFilePath string) ([]string, map[string]string, error) {
 	var envSlice []string
@@ -487,9 +569,9 @@ func GetSliceFromEnvFile(envFilePath string) ([]string, map[string]string, error
 			if !matched {
 				return nil, nil, fmt.Errorf("Environment variables in the .env file should be formatted as a line separated Key=Value pair.")
 			}
-			splitEnv := strings.Split(text, "=")
+			splitEnv := strings.SplitN(text, "=", 2)
 			envMap[splitEnv[0]] = splitEnv[1]
-			envSlice = append(envSlice, fmt.Sprintf(`{"key": "%v", "value": "%v"}`, splitEnv[0], splitEnv[1]))
+			envSlice = append(envSlice, envEntry(splitEnv[0], splitEnv[1]))
 		}
 
 		for count := 0; count < len(envSlice)-1; count++ {
@@ -500,6 +582,47 @@ func GetSliceFromEnvFile(envFilePath string) ([]string, map[string]string, error
 	return envSlice, envMap, nil
 }
 
+// API to send a request with the token, and read the response.
+func (cli_api *AppAPI) requestAPI(method string, payload io.Reader, token string) ([]byte, error) {
+	req, err := http.NewRequest(method, cli_api.baseURL, payload)
+	if err != nil {
+		return nil, fmt.Errorf("Http request failed with error: %v", err)
+	}
+
+	idToken := fmt.Sprintf("Bearer %s", token)
+	req.Header.Add("Authorization", idToken)
+	if payload != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+
+	resp, err := cli_api.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed with error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the response. Error: %v", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return data, fmt.Errorf("Not found.")
+	}
+	if errStatus := checkStatusCode(resp.StatusCode); errStatus != nil {
+		return data, errStatus
+	}
+	return data, nil
+}
+
+// To decode a JSON response into a new map.
+func decodeResponse(data []byte) (map[string]interface{}, error) {
+	var response map[string]interface{}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("Failed to parse the response. Error: %s", err)
+	}
+	return response, nil
+}
+
 // Check the status codes from app-controller.
 func checkStatusCode(statusCode int) error {
 	switch statusCode {
@@ -1,6 +1,7 @@
 package appAPIs
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
@@ -80,6 +81,7 @@ func TestCreateApp(t *testing.T) {
 		env               []string
 		envFilePath       string
 		port              string
+		options           ContainerOptions
 		token             string
 		responseCode      int
 		expectedErrPrefix string
@@ -117,6 +119,37 @@ func TestCreateApp(t *testing.T) {
 			responseCode:      http.StatusOK,
 			expectedErrPrefix: "",
 		},
+		"TestNoEnvNoPort": {
+			name:              "noPort",
+			image:             "public/someimage",
+			token:             dummyToken,
+			responseCode:      http.StatusOK,
+			expectedErrPrefix: "",
+		},
+		"TestResources": {
+			name:  "resources",
+			image: "public/someimage",
+			env:   []string{"TEST_ENV=true"},
+			port:  "8888",
+			options: ContainerOptions{Resources: &Resources{
+				Requests: map[string]string{"cpu": "250m", "memory": "512Mi"},
+				Limits:   map[string]string{"memory": "1Gi"},
+			}},
+			token:             dummyToken,
+			responseCode:      http.StatusOK,
+			expectedErrPrefix: "",
+		},
+		"TestCommand": {
+			name:  "worker",
+			image: "public/someimage",
+			options: ContainerOptions{
+				Command: []string{"/app/server"},
+				Args:    []string{"--mode", "worker"},
+			},
+			token:             dummyToken,
+			responseCode:      http.StatusOK,
+			expectedErrPrefix: "",
+		},
 		"TestFailBadRequest": {
 			name:              "noEnvFail",
 			image:             "public/someimage",
@@ -132,13 +165,25 @@ func TestCreateApp(t *testing.T) {
 
 	for testName, test := range createAppCases {
 		httpmock.Activate()
+		var payload map[string]interface{}
 		httpmock.RegisterResponder(http.MethodPost, constants.APPURL, func(req *http.Request) (*http.Response, error) {
-
+			if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+				t.Errorf("test case %s: invalid JSON payload: %v", testName, err)
+			}
 			return httpmock.NewJsonResponse(test.responseCode, map[string]string{
 				"Message": testName,
 			})
 		})
-		err := CreateApp(test.name, test.image, test.username, test.password, test.env, test.envFilePath, test.port, test.token)
+		err := CreateApp(test.name, test.image, test.username, test.password, test.env, test.envFilePath, test.port, test.options, test.token)
+		// Every container option set must be in the payload.
+		var expected map[string]interface{}
+		data, _ := json.Marshal(test.options)
+		json.Unmarshal(data, &expected)
+		for key, value := range expected {
+			if fmt.Sprint(payload[key]) != fmt.Sprint(value) {
+				t.Errorf("test case %s: expected %s %v in payload, got %v", testName, key, value, payload[key])
+			}
+		}
 		if err != nil {
 			if !strings.HasPrefix(err.Error(), test.expectedErrPrefix) {
 				errMessage := fmt.Errorf("failed test case %s with error: %s\n", testName, err.Error())
@@ -0,0 +1,61 @@
+package appAPIs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/platform9/appctl/pkg/constants"
+)
+
+// Endpoint of the domains of an app, or of one domain.
+func domainsURL(appName string, domain ...string) string {
+	return strings.Join(append([]string{constants.APPURL, appName, "domains"}, domain...), "/")
+}
+
+// To map a custom domain to an app. Returns the domain mapping.
+func CreateDomainMapping(appName string, domain string, token string) (map[string]interface{}, error) {
+	payload, err := json.Marshal(map[string]string{"domain": domain})
+	if err != nil {
+		return nil, err
+	}
+	cli_api := AppAPI{&http.Client{}, domainsURL(appName)}
+	data, err := cli_api.requestAPI("POST", strings.NewReader(string(payload)), token)
+	if err != nil {
+		return nil, checkErrors(fmt.Errorf("%v: %v", err, string(data)))
+	}
+	if len(data) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	return decodeResponse(data)
+}
+
+// To list the custom domains of an app.
+func ListDomainMappings(appName string, token string) (map[string]interface{}, error) {
+	cli_api := AppAPI{&http.Client{}, domainsURL(appName)}
+	data, err := cli_api.requestAPI("GET", nil, token)
+	if err != nil {
+		return nil, checkErrors(err)
+	}
+	return decodeResponse(data)
+}
+
+// To get a custom domain of an app.
+func GetDomainMapping(appName string, domain string, token string) (map[string]interface{}, error) {
+	cli_api := AppAPI{&http.Client{}, domainsURL(appName, domain)}
+	data, err := cli_api.requestAPI("GET", nil, token)
+	if err != nil {
+		return nil, checkErrors(err)
+	}
+	return decodeResponse(data)
+}
+
+// To remove a custom domain of an app.
+func DeleteDomainMapping(appName string, domain string, token string) error {
+	cli_api := AppAPI{&http.Client{}, domainsURL(appName, domain)}
+	if _, err := cli_api.requestAPI("DELETE", nil, token); err != nil {
+		return checkErrors(err)
+	}
+	return nil
+}
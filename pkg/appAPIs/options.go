@@ -0,0 +1,65 @@
+package appAPIs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Settings of the app container beyond the image, port and environment.
+// All are optional, unset ones use the server defaults.
+type ContainerOptions struct {
+	Resources *Resources `json:"resources,omitempty"`
+	// Entrypoint of the container and its arguments, instead of the ones
+	// of the image.
+	Command []string `json:"command,omitempty"`
+	Args    []string `json:"args,omitempty"`
+	// Name of the registry credentials to pull the image with, added with
+	// `appctl registry add`.
+	Registry string `json:"registry,omitempty"`
+	// Name of the app port, h2c for HTTP/2 and gRPC apps.
+	PortName string `json:"portName,omitempty"`
+	// Labels of the app, eg. the team owning it.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations of the revision, eg. the Knative autoscaling ones.
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Requests a container handles at a time, 0 for no limit.
+	ContainerConcurrency *int `json:"containerConcurrency,omitempty"`
+	// Probes of the app container, to tell when it can take requests and
+	// when it is stuck and must be restarted.
+	ReadinessProbe *Probe `json:"readinessProbe,omitempty"`
+	LivenessProbe  *Probe `json:"livenessProbe,omitempty"`
+}
+
+// Compute resources of the app container, as Kubernetes quantities keyed
+// by resource name, eg. "cpu": "500m" or "memory": "512Mi".
+type Resources struct {
+	Requests map[string]string `json:"requests,omitempty"`
+	Limits   map[string]string `json:"limits,omitempty"`
+}
+
+// HTTP probe of the app container, as in the Kubernetes container spec.
+// Without a port the probe is sent to the port the app listens on.
+type Probe struct {
+	HTTPGet        *HTTPGetAction `json:"httpGet,omitempty"`
+	PeriodSeconds  int            `json:"periodSeconds,omitempty"`
+	TimeoutSeconds int            `json:"timeoutSeconds,omitempty"`
+}
+
+// Request of an HTTP probe, a 2xx or 3xx response passes it.
+type HTTPGetAction struct {
+	Path string `json:"path"`
+}
+
+// To add the container options to the JSON payload of a create request.
+func withContainerOptions(createInfo string, options ContainerOptions) (string, error) {
+	data, err := json.Marshal(options)
+	if err != nil {
+		return "", fmt.Errorf("Failed to marshal container options with error: %v", err)
+	}
+	if string(data) == "{}" {
+		return createInfo, nil
+	}
+	createInfo = strings.TrimSuffix(strings.TrimSpace(createInfo), "}")
+	return fmt.Sprintf("%s, %s", createInfo, strings.TrimPrefix(string(data), "{")), nil
+}
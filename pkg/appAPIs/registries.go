@@ -0,0 +1,82 @@
+package appAPIs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/platform9/appctl/pkg/constants"
+)
+
+// Credentials of a private container registry, stored on the server as a
+// pull secret that apps reference by name.
+type Registry struct {
+	Name     string `json:"name,omitempty"`
+	Server   string `json:"server,omitempty"`
+	Username string `json:"username,omitempty"`
+	// Never returned by the server.
+	Password string `json:"password,omitempty"`
+}
+
+// Endpoint of the registries, or of one registry. Defaults to a sibling of
+// the apps endpoint, as registries are shared by apps.
+func registriesURL(name ...string) string {
+	base := constants.REGISTRYURL
+	if base == "" {
+		base = constants.APPURL[:strings.LastIndex(constants.APPURL, "/")+1] + "registries"
+	}
+	return strings.Join(append([]string{base}, name...), "/")
+}
+
+// To add the credentials of a registry.
+func CreateRegistry(registry Registry, token string) error {
+	payload, err := json.Marshal(registry)
+	if err != nil {
+		return err
+	}
+	cli_api := AppAPI{&http.Client{}, registriesURL()}
+	if data, err := cli_api.requestAPI("POST", strings.NewReader(string(payload)), token); err != nil {
+		return checkErrors(fmt.Errorf("%v: %v", err, string(data)))
+	}
+	return nil
+}
+
+// To list the registries, without their passwords.
+func ListRegistries(token string) ([]Registry, error) {
+	cli_api := AppAPI{&http.Client{}, registriesURL()}
+	data, err := cli_api.requestAPI("GET", nil, token)
+	if err != nil {
+		return nil, checkErrors(err)
+	}
+	var registries struct {
+		Items []Registry `json:"items"`
+	}
+	if err := json.Unmarshal(data, &registries); err != nil {
+		return nil, fmt.Errorf("Failed to parse the response. Error: %s", err)
+	}
+	return registries.Items, nil
+}
+
+// To replace the credentials of a registry, the apps using it pull with the
+// new ones from then on.
+func UpdateRegistry(registry Registry, token string) error {
+	payload, err := json.Marshal(registry)
+	if err != nil {
+		return err
+	}
+	cli_api := AppAPI{&http.Client{}, registriesURL(registry.Name)}
+	if data, err := cli_api.requestAPI("PUT", strings.NewReader(string(payload)), token); err != nil {
+		return checkErrors(fmt.Errorf("%v: %v", err, string(data)))
+	}
+	return nil
+}
+
+// To delete the credentials of a registry.
+func DeleteRegistry(name string, token string) error {
+	cli_api := AppAPI{&http.Client{}, registriesURL(name)}
+	if _, err := cli_api.requestAPI("DELETE", nil, token); err != nil {
+		return checkErrors(err)
+	}
+	return nil
+}
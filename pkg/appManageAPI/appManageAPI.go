@@ -2,10 +2,12 @@ package appManageAPI
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	isconnect "github.com/alimasyhur/is-connect"
@@ -14,8 +16,10 @@ import (
 	"github.com/platform9/appctl/pkg/appAPIs"
 	"github.com/platform9/appctl/pkg/browser"
 	"github.com/platform9/appctl/pkg/constants"
+	"github.com/platform9/appctl/pkg/jwks"
 	"github.com/platform9/appctl/pkg/segment"
 	"github.com/ryanuber/columnize"
+	"github.com/skip2/go-qrcode"
 )
 
 // Config structure for configfile.
@@ -24,6 +28,9 @@ type Config struct {
 	ExpiresAt time.Time
 }
 
+// Returned by getTokenClaims for a valid but expired token.
+var errTokenExpired = errors.New("Token is expired")
+
 type Event struct {
 	EventName string
 	Status    string
@@ -39,16 +46,10 @@ func ListAppsInfo() error {
 		return fmt.Errorf("Network unreachable. %v\n", constants.InternetConnectivity)
 	}
 
-	// Load config, and check if id_token expired
-	config, err := loadConfig(constants.CONFIGFILEPATH)
+	// Load the token, from APPCTL_TOKEN or the config file.
+	token, err := loadToken("list apps")
 	if err != nil {
-		return fmt.Errorf("Failed to list apps. Please login using command `appctl login`.\n")
-	}
-
-	// Check if Token is expired or not.
-	expired, _ := checkTokenExpired(config.IDToken)
-	if expired {
-		return fmt.Errorf("Login expired. Please login again using command `appctl login`\n")
+		return err
 	}
 
 	// To list and store output.
@@ -58,7 +59,7 @@ func ListAppsInfo() error {
 	Output = append(Output, constants.TABLEFORMAT)
 
 	// Fetch the running apps.
-	list_apps, err := appAPIs.ListApps(config.IDToken)
+	list_apps, err := appAPIs.ListApps(token)
 	if err != nil {
 		//Event is Failure.
 		event.EventName = "List-Apps"
@@ -104,6 +105,10 @@ func ListAppsInfo() error {
 
 			}
 		}
+		// Pinned images show the tag they were deployed from.
+		if app, ok := items.(map[string]interface{}); ok && appContainer(app) != nil {
+			list.Image = displayImage(app)
+		}
 		event.Data = append(event.Data, list)
 		appinfo := fmt.Sprintf("%v | %v | %v | %v | %v | %v", list.Name, list.URL, list.Image, list.ReadyStatus, list.CreationTime, list.Reason)
 		Output = append(Output, appinfo)
@@ -127,6 +132,7 @@ func CreateApp(
 	env []string, // Environment varialbes of app.
 	envFilePath string, // File path to environment variables
 	port string, // Port where application listens on.
+	options appAPIs.ContainerOptions, // Resources and other container settings.
 ) error {
 	if name == "" || image == "" {
 		return fmt.Errorf("Either or both of app name and image not specified.\n")
@@ -137,20 +143,14 @@ func CreateApp(
 		return fmt.Errorf("Network unreachable. %v\n", constants.InternetConnectivity)
 	}
 
-	// Load config, and check if id_token expired
-	config, err := loadConfig(constants.CONFIGFILEPATH)
+	// Load the token, from APPCTL_TOKEN or the config file.
+	token, err := loadToken("deploy app")
 	if err != nil {
-		return fmt.Errorf("Failed to deploy app. Please login using command `appctl login`.\n")
-	}
-
-	// Check if Token is expired or not.
-	expired, _ := checkTokenExpired(config.IDToken)
-	if expired {
-		return fmt.Errorf("Login expired. Please login again using command `appctl login`\n")
+		return err
 	}
 
 	// To check if app with same name already exists.
-	appExists, err := appAPIs.GetAppByName(name, config.IDToken)
+	appExists, err := appAPIs.GetAppByName(name, token)
 	if err == nil && appExists != nil {
 		return fmt.Errorf("App with same name already exists!! Please use different name.\n")
 	}
@@ -162,7 +162,7 @@ func CreateApp(
 	s.Start()
 	s.Suffix = " Deploying app.."
 
-	errCreate := appAPIs.CreateApp(name, image, username, password, env, envFilePath, port, config.IDToken)
+	errCreate := appAPIs.CreateApp(name, image, username, password, env, envFilePath, port, options, token)
 	if errCreate != nil {
 		//Event is Failure.
 		event.EventName = "Deploy-App"
@@ -176,68 +176,56 @@ func CreateApp(
 		}
 		return fmt.Errorf("%v\n", errCreate)
 	}
+	invalidateAppNames()
 
-	time.Sleep(constants.APPDEPLOYINTERVAL * time.Second)
+	sleep(constants.APPDEPLOYINTERVAL * time.Second)
 	// Polling to fetch URL if app is deployed.
-	var count = 0
-	var status, securedAppURL bool
-	var invalidImage string
-	for count <= constants.APPDEPLOYINTERVAL {
-		count++
-		// Fetch the detailedapp information for given appname.
-		get_app, err := appAPIs.GetAppByName(name, config.IDToken)
-		if err != nil {
-			time.Sleep(constants.APPDEPLOYINTERVAL * time.Second)
-			continue
-		}
-		// It takes time to get all routes, configuration, ready state up and running.
-		status, invalidImage = checkStatusReady(get_app)
-		if invalidImage != "" {
-			//Event is Failure.
-			event.EventName = "Deploy-App"
-			event.Status = "Failure"
-			event.Error = invalidImage
-			send(event, get_app)
-			s.Stop()
-			return fmt.Errorf("%v %v.\nPlease check if the application image path provided is valid, and is from a public registry.\n", invalidImage, image)
-		}
-		if !status {
-			// Wait until stauts of app deployed is ready and true.
-			time.Sleep(constants.APPDEPLOYINTERVAL * time.Second)
-			continue
-		}
-
-		// URL Endpoint where the app service is available.
-		url := (get_app["status"]).(map[string]interface{})["url"]
-		if url != nil && status {
-
-			// Check if app url is secured.
-			securedAppURL = checkSecuredURL(url)
-			if !securedAppURL {
-				time.Sleep(constants.SECUREENDPOINT * time.Second)
-				continue
-			}
-
-			s.Stop()
-			fmt.Printf("\nApp %v is deployed and can be accessed at URL: %v\n", name, url)
-			//Event is Successful.
-			event.EventName = "Deploy-App"
-			event.Status = "Success"
-			send(event, get_app)
-			return nil
-		} else {
-			s.Stop()
-			fmt.Printf("\nApp deploy taking time. Check latest status by running command `appctl list`.\n")
-			return nil
-		}
+	// It takes time to get all routes, configuration, ready state up and running.
+	get_app, ready, invalidImage := pollUntilReady(
+		func() (map[string]interface{}, error) {
+			// Fetch the detailedapp information for given appname.
+			return appAPIs.GetAppByName(name, token)
+		},
+		appReady,
+		constants.APPDEPLOYINTERVAL+1,
+		constants.APPDEPLOYINTERVAL*time.Second,
+	)
+	s.Stop()
+	if invalidImage != "" {
+		//Event is Failure.
+		event.EventName = "Deploy-App"
+		event.Status = "Failure"
+		event.Error = invalidImage
+		send(event, get_app)
+		return fmt.Errorf("%v %v.\nPlease check if the application image path provided is valid, and is from a public registry.\n", invalidImage, image)
 	}
-	if !status || !securedAppURL {
-		s.Stop()
+	if !ready {
 		fmt.Printf("\nApp deploy taking time. Check latest status by running command `appctl list`.\n")
+		return nil
 	}
+
+	// URL Endpoint where the app service is available.
+	url := (get_app["status"]).(map[string]interface{})["url"]
+	fmt.Printf("\nApp %v is deployed and can be accessed at URL: %v\n", name, url)
+	//Event is Successful.
+	event.EventName = "Deploy-App"
+	event.Status = "Success"
+	send(event, get_app)
 	return nil
 }
 
+// Check if the app is ready and its URL is secured, or if the image is invalid.
+func appReady(get_app map[string]interface{}) (bool, string) {
+	status, invalidImage := checkStatusReady(get_app)
+	if invalidImage != "" || !status {
+		// Wait until stauts of app deployed is ready and true.
+		return false, invalidImage
+	}
+	url := (get_app["status"]).(map[string]interface{})["url"]
+	// Check if app url is secured.
+	return url != nil && checkSecuredURL(url), ""
+}
+
 // Check if all three status are true and ready.
 func checkStatusReady(get_app map[string]interface{}) (bool, string) {
 	var configurationStatus, readyStatus, routeStatus string
@@ -267,6 +255,8 @@ func checkStatusReady(get_app map[string]interface{}) (bool, string) {
 // To get a detailed information of particular app by name.
 func GetAppByNameInfo(
 	name string, // app name
+	output string, // output format, json or text
+	showSecrets bool, // to show the values of env variables that look like secrets
 ) error {
 	if name == "" {
 		return fmt.Errorf("App name not specified.\n")
@@ -277,24 +267,17 @@ func GetAppByNameInfo(
 		return fmt.Errorf("Network unreachable. %v\n", constants.InternetConnectivity)
 	}
 
-	// Load config, and check if id_token expired
-	config, err := loadConfig(constants.CONFIGFILEPATH)
+	// Load the token, from APPCTL_TOKEN or the config file.
+	token, err := loadToken("get app information")
 	if err != nil {
-		return fmt.Errorf("Failed to get app information. Please login using command `appctl login`.\n")
-	}
-
-	// Check if Token is expired or not.
-	expired, _ := checkTokenExpired(config.IDToken)
-
-	if expired {
-		return fmt.Errorf("Login expired. Please login again using command `appctl login`\n")
+		return err
 	}
 
 	// Send Segment Event
 	var event Event
 
 	// Fetch the detailedapp information for given appname.
-	get_app, err := appAPIs.GetAppByName(name, config.IDToken)
+	get_app, err := appAPIs.GetAppByName(name, token)
 	if err != nil {
 		//Event is Failure.
 		event.EventName = "Describe-App"
@@ -307,6 +290,13 @@ func GetAppByNameInfo(
 	event.EventName = "Describe-App"
 	event.Status = "Success"
 	send(event, get_app)
+	if !showSecrets {
+		get_app = maskAppSecrets(get_app)
+	}
+	if output == DescribeText {
+		printAppDetails(get_app)
+		return nil
+	}
 	jsonFormatted, err := json.MarshalIndent(get_app, "", "  ")
 	if err != nil {
 		return err
@@ -315,8 +305,17 @@ func GetAppByNameInfo(
 	return nil
 }
 
+// Hooks used by the login flow, replaced in tests.
+var (
+	openBrowser = browser.OpenBrowser
+	sleep       = time.Sleep
+	now         = time.Now
+)
+
 // To login using Device authentication and access appctl.
-func LoginApp() error {
+// With noBrowser set, the verification URL is printed along with a QR code
+// instead of opening a browser, for use over SSH sessions.
+func LoginApp(noBrowser bool) error {
 	s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
 	s.Color("red")
 
@@ -335,67 +334,99 @@ func LoginApp() error {
 	fmt.Printf("Device verification is required to continue login.\n")
 	fmt.Printf("Your Device Confirmation code is: %v\n", deviceCode.UserCode)
 
-	// To open browser, for device verification and SSO.
-	err = browser.OpenBrowser(deviceCode.VerificationUrlComplete)
-	if err != nil {
-		fmt.Printf("\nCouldn't open the URL, kindly do it manually: %v\n", deviceCode.VerificationUrlComplete)
+	if noBrowser {
+		printVerificationURL(deviceCode)
+	} else {
+		// To open browser, for device verification and SSO.
+		err = openBrowser(deviceCode.VerificationUrlComplete)
+		if err != nil {
+			fmt.Printf("\nCouldn't open the URL, kindly do it manually.\n")
+			printVerificationURL(deviceCode)
+		}
 	}
 
-	var Token *appAPIs.TokenInfo
-
 	// Wait for device verification in browser and if its success request the token.
-	s.Start()
 	s.Suffix = " Waiting for login to complete in browser..."
+	s.Start()
 
 	// Send Segment Event
 	var event Event
-	for true {
-		// Request for token polling.
-		Token, err = appAPIs.RequestToken(deviceCode.DeviceCode)
-		if err != nil {
-			fmt.Printf("Falied to fetch token Error:%s", err)
-		}
+	Token, err := pollToken(deviceCode)
+	s.Stop()
+	if err != nil {
+		//Event is Failure.
+		event.EventName = "Login"
+		event.Status = "Failure"
+		event.Error = err.Error()
+		send(event, nil)
+		return fmt.Errorf("\nCannot login. Failed to fetch token with error: %v\n", err)
+	}
 
-		// If token is fetched, then next write to config.
-		if Token.IdToken != "" {
-			s.Stop()
-			break
-		}
+	// If device code is expired.
+	if Token.Error == "expired_token" {
+		//Event is Failure.
+		event.EventName = "Login"
+		event.Status = "Failure"
+		event.Error = Token.Error
+		send(event, nil)
+		return fmt.Errorf("\nThe device code was expired as the app was not authorized in time!\n" +
+			"Login again using `appctl login`!!\n")
+	}
 
-		// If authorization is still pending in browser.
-		if Token.Error == "authorization_pending" {
-			// This is time interval we can poll for token as per auth0 docs.
-			time.Sleep(constants.TOKENPOLLINTERVAL * time.Second)
-			continue
-		}
+	// If access is Denied, or any other error from the authorization server.
+	if Token.IdToken == "" {
+		//Event is Failure.
+		event.EventName = "Login"
+		event.Status = "Failure"
+		event.Error = Token.Error
+		send(event, nil)
+		return fmt.Errorf("\nCannot login. Please try again.\n")
+	}
 
-		// If device code is expired.
-		if Token.Error == "expired_token" {
-			s.Stop()
-			//Event is Failure.
-			event.EventName = "Login"
-			event.Status = "Failure"
-			event.Error = Token.Error
-			send(event, nil)
-			return fmt.Errorf("\nThe device code was expired as the app was not authorized in time!\n" +
-				"Login again using `appctl login`!!\n")
-		}
+	return saveLogin(Token.IdToken, Token.ExpiresIn)
+}
 
-		// If access is Denied.
-		if Token.Error == "access_denied" {
-			s.Stop()
-			//Event is Failure.
-			event.EventName = "Login"
-			event.Status = "Failure"
-			event.Error = Token.Error
-			send(event, nil)
-			return fmt.Errorf("\nCannot login. Please try again.\n")
-		}
+// To login with the OAuth client credentials grant, for CI runners that
+// can't complete the device verification in a browser.
+func LoginClientCredentials(clientID string, clientSecret string) error {
+	if clientID == "" || clientSecret == "" {
+		return fmt.Errorf("Both client id and client secret are required.\n")
+	}
+
+	//Check Internet Connectivity
+	if !isconnect.IsOnline() {
+		return fmt.Errorf("Network unreachable. %v\n", constants.InternetConnectivity)
 	}
+
+	// Send Segment Event
+	var event Event
+	Token, err := appAPIs.RequestClientCredentialsToken(clientID, clientSecret)
+	if err == nil {
+		// Make sure the token is one appctl can use before saving it.
+		_, err = getTokenClaims(Token.AccessToken)
+	}
+	if err != nil {
+		//Event is Failure.
+		event.EventName = "Login"
+		event.Status = "Failure"
+		event.Error = err.Error()
+		send(event, nil)
+		return fmt.Errorf("Cannot login with client credentials. Error: %v\n", err)
+	}
+
+	return saveLogin(Token.AccessToken, Token.ExpiresIn)
+}
+
+// To save the token to the config file, and register the login with the
+// app-controller.
+func saveLogin(idToken string, expiresIn int) error {
+	// Send Segment Event
+	var event Event
+
 	// To create and write to config file.
 	var config = Config{
-		IDToken:   Token.IdToken,
-		ExpiresAt: time.Now().Add(time.Duration(Token.ExpiresIn) * time.Second),
+		IDToken:   idToken,
+		ExpiresAt: time.Now().Add(time.Duration(expiresIn) * time.Second),
 	}
 
 	errConfig := createConfig(config, constants.CONFIGFILEPATH)
@@ -426,6 +457,74 @@ func LoginApp() error {
 	return nil
 }
 
+// Poll for the token until the device is verified, as per RFC 8628.
+// Polls at the interval given by the server, backs off on slow_down, and
+// gives up once the device code expires. Returns the final token response,
+// which either has the IdToken or the error reported by the server.
+func pollToken(deviceCode *appAPIs.DeviceInfo) (*appAPIs.TokenInfo, error) {
+	interval := time.Duration(deviceCode.Interval) * time.Second
+	if interval <= 0 {
+		interval = constants.TOKENPOLLINTERVAL * time.Second
+	}
+	expiresIn := time.Duration(deviceCode.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = constants.DEVICECODEEXPIRY * time.Second
+	}
+	deadline := now().Add(expiresIn)
+
+	var lastErr error
+	for {
+		sleep(interval)
+		if now().After(deadline) {
+			if lastErr != nil {
+				return nil, lastErr
+			}
+			return &appAPIs.TokenInfo{Error: "expired_token"}, nil
+		}
+
+		// Request for token polling.
+		Token, err := appAPIs.RequestToken(deviceCode.DeviceCode)
+		if err != nil {
+			// Network errors are retried until the device code expires.
+			lastErr = err
+			continue
+		}
+		lastErr = nil
+
+		// If token is fetched, then next write to config.
+		if Token.IdToken != "" {
+			return Token, nil
+		}
+
+		switch Token.Error {
+		case "authorization_pending":
+			// Authorization is still pending in browser.
+			continue
+		case "slow_down":
+			// Polling too fast, the interval must be increased by 5 seconds.
+			interval += constants.TOKENPOLLSLOWDOWN * time.Second
+			continue
+		default:
+			// expired_token, access_denied or any other error is final.
+			return Token, nil
+		}
+	}
+}
+
+// Print the verification URL, and a QR code to scan it from a phone.
+func printVerificationURL(deviceCode *appAPIs.DeviceInfo) {
+	url := deviceCode.VerificationUrlComplete
+	if url == "" {
+		url = deviceCode.Verification_URL
+	}
+	fmt.Printf("\nOpen the following URL in a browser to continue login: %v\n", url)
+	qr, err := qrcode.New(url, qrcode.Low)
+	if err != nil {
+		return
+	}
+	fmt.Printf("Or scan the QR code:\n\n%v\n", qr.ToSmallString(false))
+}
+
 // To get a detailed information of particular app by name.
 func DeleteApp(
 	name string, // app name
@@ -439,24 +538,25 @@ func DeleteApp(
 		return fmt.Errorf("Network unreachable. %v\n", constants.InternetConnectivity)
 	}
 
-	// Load config, and check if id_token expired
-	config, err := loadConfig(constants.CONFIGFILEPATH)
+	// Load the token, from APPCTL_TOKEN or the config file.
+	token, err := loadToken("delete app")
 	if err != nil {
-		return fmt.Errorf("Failed to delete app. Please login using command `appctl login`.\n")
+		return err
 	}
 
-	// Check if Token is expired or not.
-	expired, _ := checkTokenExpired(config.IDToken)
-
-	if expired {
-		return fmt.Errorf("Login expired. Please login again using command `appctl login`\n")
+	errDel := deleteApp(name, token)
+	if errDel != nil {
+		return fmt.Errorf("Failed to delete app with error: %v\nCheck 'appctl list' for more information on apps running.\n", errDel)
 	}
+	return nil
+}
 
+// To delete an app by name with an already loaded token.
+func deleteApp(name string, token string) error {
 	// To check if app exists.
-
-	get_app, errApp := appAPIs.GetAppByName(name, config.IDToken)
+	get_app, errApp := appAPIs.GetAppByName(name, token)
 	if errApp != nil {
-		return fmt.Errorf("Failed to delete app with error: %v\nCheck 'appctl list' for more information on apps running.\n", errApp)
+		return errApp
 	}
 	// Fetch app info prior to deletion.
 	var event Event
@@ -464,16 +564,18 @@ func DeleteApp(
 	event.Data = append(event.Data, *appInfo)
 
 	// Fetch the detailedapp information for given appname.
-	errDel := appAPIs.DeleteAppByName(name, config.IDToken)
+	errDel := appAPIs.DeleteAppByName(name, token)
 	if errDel != nil {
 		//Event is Failure.
 		event.EventName = "Delete-App"
 		event.Status = "Failure"
 		event.Error = errDel.Error()
 		send(event, nil)
-		return fmt.Errorf("Failed to delete app with error: %v\nCheck 'appctl list' for more information on apps running.\n", errDel)
+		return errDel
 	}
 
+	invalidateAppNames()
+
 	// Send Segment Event
 	event.EventName = "Delete-App"
 	event.Status = "Success"
@@ -530,19 +632,50 @@ func removeConfig(configFilePath string) error {
 	return nil
 }
 
-// To send a segment event.
-func send(event Event, get_app map[string]interface{}) error {
-	// Create a new Segment client
-	client, err := segment.SegmentClient()
-	if err != nil {
-		return err
+// Tracker for usage events, created on first use for the telemetry mode.
+// Replaced with a segment.NopTracker in tests.
+var (
+	trackerLock sync.Mutex
+	tracker     segment.Tracker
+)
+
+func currentTracker() segment.Tracker {
+	trackerLock.Lock()
+	defer trackerLock.Unlock()
+	if tracker == nil {
+		// Payloads are printed to stderr in show mode, so the command output stays the same.
+		var err error
+		tracker, err = segment.NewTracker(os.Stderr)
+		if err != nil {
+			tracker = segment.NopTracker{}
+		}
 	}
+	return tracker
+}
+
+// To send the queued usage events before exit, waits at most for the
+// telemetry shutdown timeout.
+func CloseTracker() {
+	trackerLock.Lock()
+	defer trackerLock.Unlock()
+	if tracker != nil {
+		tracker.Close()
+		tracker = nil
+	}
+}
+
+// To send a segment event, and note it for the audit log.
+func send(event Event, get_app map[string]interface{}) error {
+	// Events are queued, and sent in the background until CloseTracker.
+	client := currentTracker()
 
-	defer segment.Close(client)
+	// Fetch the UserID and loginType
+	userId, loginType, _ := fetchUserId()
 
 	// Segment event for List Apps
 	if event.EventName == "List-Apps" || event.EventName == "Login" {
-		userId, loginType, _ := fetchUserId()
+		event = maskEvent(event)
+		noteAudit(event, "")
 		if err := segment.SendEventList(client, event.EventName, userId, event.Status, loginType, event.Error, event.Data); err != nil {
 			return fmt.Errorf("Failed to send segment event. Error: %v\n", err)
 		}
@@ -553,8 +686,12 @@ func send(event Event, get_app map[string]interface{}) error {
 			appInfo, _ := fetchAppInfo(get_app)
 			event.Data = append(event.Data, *appInfo)
 		}
-		// Fetch the UserID and loginType
-		userId, loginType, _ := fetchUserId()
+		var appName string
+		if len(event.Data) > 0 {
+			appName = event.Data[0].Name
+		}
+		event = maskEvent(event)
+		noteAudit(event, appName)
 		if err := segment.SendEvent(client, event.EventName, userId, event.Status, loginType, event.Error, event.Data); err != nil {
 			return fmt.Errorf("Failed to send segment event. Error: %v\n", err)
 		}
@@ -566,26 +703,31 @@ func send(event Event, get_app map[string]interface{}) error {
 // To fetch UserID, and login type after basic validation of token.
 func fetchUserId() (string, string, error) {
 
-	// Load config, and fetch the IDToken
-	config, err := loadConfig(constants.CONFIGFILEPATH)
-	if err != nil {
-		return "", "", fmt.Errorf("Failed to load config. Please login using command `appctl login`.\n")
+	// Load the token, from APPCTL_TOKEN or the config file. Expired tokens
+	// still tell who the user is, failures after the login expired are theirs.
+	token := savedToken()
+	if token == "" {
+		return "", "", fmt.Errorf("Failed to load token. Please login using command `appctl login`.\n")
 	}
 	// Get the token claims.
-	claims, err := getTokenClaims(config.IDToken)
-	if err != nil {
+	claims, err := getTokenClaims(token)
+	if err != nil && err != errTokenExpired {
 		return "", "", fmt.Errorf("%v", err)
 	}
 
 	var userId, loginType string
 
 	// Email is empty if token is github login generated.
+	// Tokens from client credentials have neither, only the client as subject.
 	if claims["email"] != nil {
 		userId = fmt.Sprintf("%v", claims["email"])
 		loginType = "google-auth"
-	} else {
+	} else if claims["nickname"] != nil {
 		userId = fmt.Sprintf("%v", claims["nickname"])
 		loginType = "github"
+	} else {
+		userId = fmt.Sprintf("%v", claims["sub"])
+		loginType = "client-credentials"
 	}
 
 	return userId, loginType, nil
@@ -632,53 +774,68 @@ func fetchAppInfo(get_app map[string]interface{}) (*constants.ListAppInfo, error
 	return &constants.ListAppInfo{Name: name, URL: url, Image: image, Port: port, ReadyStatus: readyStatus, CreationTime: creationTime, Reason: reason}, nil
 }
 
-// Basic token validation, and get claims.
+// Signing keys of the token issuer, used to verify the ID token.
+var keySet = jwks.NewKeySet(constants.JWKSURL, constants.JWKSCACHEFILEPATH)
+
+// Verify the token signature against the issuer's keys, and get claims.
 func getTokenClaims(idToken string) (jwt.MapClaims, error) {
-	// Parse the token.
-	tokens, err := jwt.Parse(idToken, nil)
+	claims := jwt.MapClaims{}
+	// Parse the token, this checks the signature, exp and nbf.
+	parser := jwt.Parser{ValidMethods: []string{"RS256"}}
+	tokens, err := parser.ParseWithClaims(idToken, claims, keySet.Keyfunc)
 	if tokens == nil {
-		//fmt.Printf("Empty with error :%v", err)
 		return jwt.MapClaims{}, fmt.Errorf("Empty with error:%v", err)
 	}
+	// Expired tokens are reported as such, so callers can ask to login again,
+	// once the rest of the token is checked.
+	expired := false
+	if err != nil {
+		validationErr, ok := err.(*jwt.ValidationError)
+		if !ok || validationErr.Errors != jwt.ValidationErrorExpired {
+			return jwt.MapClaims{}, fmt.Errorf("Token is invalid: %v", err)
+		}
+		expired = true
+	}
 
-	//Fetch Claims
-	claims, _ := tokens.Claims.(jwt.MapClaims)
-
-	// Doing simple additional validation i.e if audiance == auth0 clientID
-	if claims["aud"] != constants.CLIENTID {
+	// Token should be issued by our auth0 tenant for the appctl client.
+	if !claims.VerifyIssuer(constants.ISSUER, true) {
+		return jwt.MapClaims{}, fmt.Errorf("Token is invalid. Unexpected issuer.")
+	}
+	// Tokens from client credentials are issued for the app-controller API instead.
+	if !claims.VerifyAudience(constants.CLIENTID, true) &&
+		(constants.AUDIENCE == "" || !claims.VerifyAudience(constants.AUDIENCE, true)) {
 		return jwt.MapClaims{}, fmt.Errorf("Token is invalid.")
 	}
+	if expired {
+		return claims, errTokenExpired
+	}
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return jwt.MapClaims{}, fmt.Errorf("Can't fetch token expiryAt time.\n")
+	}
 
 	return claims, nil
 }
 
 func checkTokenExpired(idToken string) (bool, error) {
-	// Get the claims.
-	claims, err := getTokenClaims(idToken)
+	// Get the claims, this fails for expired tokens.
+	_, err := getTokenClaims(idToken)
+	if err == errTokenExpired {
+		return true, nil
+	}
 	if err != nil {
 		return true, fmt.Errorf("%v", err)
 	}
-	// Check if token is expired.
-	if expiry, ok := claims["exp"].(float64); ok {
-		expiryTime := time.Unix(int64(expiry), 0)
-		if expiryTime.Before(time.Now()) {
-			return true, nil
-		}
-	} else {
-		return true, fmt.Errorf("Can't fetch token expiryAt time.\n")
-	}
 	return false, nil
 }
 
-// Get the container port
+// Get the container port, Knative apps have a single one.
 func getPort(container map[string]interface{}) string {
-	for key, value := range container {
-		if key == "ports" {
-			port := value.([]interface{})[0].(map[string]interface{})["containerPort"]
-			return fmt.Sprintf("%v", port)
-		}
+	ports, _ := container["ports"].([]interface{})
+	if len(ports) == 0 {
+		return ""
 	}
-	return ""
+	port, _ := ports[0].(map[string]interface{})
+	return stringOf(port["containerPort"])
 }
 
 //appAge gives the age of app since its creation.
@@ -0,0 +1,74 @@
+package appManageAPI
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/platform9/appctl/pkg/appAPIs"
+	"github.com/platform9/appctl/pkg/constants"
+)
+
+// Names of the apps, cached for shell completion.
+type appNamesCache struct {
+	// Hash of the token the names were listed with, names of another login
+	// are never used.
+	TokenHash string    `json:"tokenHash"`
+	FetchedAt time.Time `json:"fetchedAt"`
+	Names     []string  `json:"names"`
+}
+
+// Path of the cached app names.
+func appNamesCachePath() string {
+	return filepath.Join(constants.CONFIGDIR, constants.APPNAMESCACHEFILE)
+}
+
+// To get the names of the apps, for shell completion. Names are cached for a
+// short while so completing doesn't wait on the network on every TAB press.
+// No segment event is sent.
+func AppNames() ([]string, error) {
+	token, err := currentTokenSource().Token()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(token))
+	tokenHash := hex.EncodeToString(sum[:])
+
+	var cache appNamesCache
+	if data, err := ioutil.ReadFile(appNamesCachePath()); err == nil && json.Unmarshal(data, &cache) == nil {
+		if cache.TokenHash == tokenHash && now().Sub(cache.FetchedAt) < constants.APPNAMESCACHETTL*time.Second {
+			return cache.Names, nil
+		}
+	}
+
+	list_apps, err := appAPIs.ListApps(token)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list apps with error: %v", err)
+	}
+	names := []string{}
+	items, _ := list_apps["items"].([]interface{})
+	for _, item := range items {
+		metadata, _ := item.(map[string]interface{})["metadata"].(map[string]interface{})
+		if metadata != nil && metadata["name"] != nil {
+			names = append(names, fmt.Sprintf("%v", metadata["name"]))
+		}
+	}
+	sort.Strings(names)
+
+	cache = appNamesCache{TokenHash: tokenHash, FetchedAt: now(), Names: names}
+	if data, err := json.Marshal(cache); err == nil && createDirectoryIfNotExist(constants.CONFIGDIR) == nil {
+		ioutil.WriteFile(appNamesCachePath(), data, 0600)
+	}
+	return names, nil
+}
+
+// To drop the cached app names, once apps are deployed or deleted.
+func invalidateAppNames() {
+	os.Remove(appNamesCachePath())
+}
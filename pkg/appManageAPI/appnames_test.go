@@ -0,0 +1,57 @@
+package appManageAPI
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/platform9/appctl/pkg/constants"
+)
+
+func TestAppNames(t *testing.T) {
+	useDummyLogin(t)
+	savedNow := now
+	clock := time.Now()
+	now = func() time.Time { return clock }
+	t.Cleanup(func() {
+		now = savedNow
+	})
+
+	apps := []interface{}{dummyApp("web", nil, time.Hour), dummyApp("api", nil, time.Hour)}
+	httpmock.RegisterResponder(http.MethodGet, constants.APPURL, func(req *http.Request) (*http.Response, error) {
+		return httpmock.NewJsonResponse(200, map[string]interface{}{"items": apps})
+	})
+	listCalls := func() int {
+		return httpmock.GetCallCountInfo()["GET "+constants.APPURL]
+	}
+
+	names, err := AppNames()
+	if err != nil {
+		t.Fatalf("failed with error: %v", err)
+	}
+	if strings.Join(names, " ") != "api web" {
+		t.Errorf("expected sorted app names, got %v", names)
+	}
+
+	// Served from the cache while it is fresh.
+	apps = append(apps, dummyApp("worker", nil, time.Hour))
+	clock = clock.Add(10 * time.Second)
+	if names, _ = AppNames(); len(names) != 2 || listCalls() != 1 {
+		t.Errorf("expected cached app names, got %v after %d list calls", names, listCalls())
+	}
+
+	// Listed again once stale.
+	clock = clock.Add(constants.APPNAMESCACHETTL * time.Second)
+	if names, _ = AppNames(); len(names) != 3 || listCalls() != 2 {
+		t.Errorf("expected app names to be listed again, got %v after %d list calls", names, listCalls())
+	}
+
+	// And after an app is deleted.
+	invalidateAppNames()
+	AppNames()
+	if listCalls() != 3 {
+		t.Errorf("expected app names to be listed again after invalidation, got %d list calls", listCalls())
+	}
+}
@@ -0,0 +1,66 @@
+package appManageAPI
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/platform9/appctl/pkg/audit"
+)
+
+// Operation of the running command, recorded in the audit log once the
+// command is done, so refused and failed commands are recorded too.
+var (
+	auditLock  sync.Mutex
+	auditEvent Event
+	auditApp   string
+	auditApps  int
+)
+
+// To note an operation of the running command. Commands operating on
+// several apps are recorded without an app, their names are in the args.
+func noteAudit(event Event, appName string) {
+	auditLock.Lock()
+	defer auditLock.Unlock()
+	if appName != "" && appName != auditApp {
+		auditApps++
+		auditApp = appName
+	}
+	// A failed operation isn't hidden by a later successful one.
+	if auditEvent.Status == "" || auditEvent.Status == "Success" {
+		auditEvent = event
+	}
+}
+
+// To record the running command in the audit log, if enabled, once it is
+// done. command names the event if the command sent none, err is what the
+// command returned. Secrets of the command are masked in the error.
+func RecordCommand(command string, err error) {
+	auditLock.Lock()
+	event, app := auditEvent, auditApp
+	if auditApps > 1 {
+		app = ""
+	}
+	auditLock.Unlock()
+
+	userId, loginType, _ := fetchUserId()
+	entry := audit.Entry{
+		User:      userId,
+		LoginType: loginType,
+		App:       app,
+		Event:     event.EventName,
+		Outcome:   audit.Success,
+		Error:     event.Error,
+	}
+	if entry.Event == "" {
+		entry.Event = command
+	}
+	if event.Status != "" && event.Status != "Success" {
+		entry.Outcome = audit.Failure
+	}
+	if err != nil {
+		entry.Outcome = audit.Failure
+		entry.Error = MaskSecrets(strings.TrimSpace(err.Error()))
+	}
+	// The audit log must not get in the way of the command.
+	audit.Record(entry)
+}
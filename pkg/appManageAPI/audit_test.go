@@ -0,0 +1,72 @@
+package appManageAPI
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/platform9/appctl/pkg/audit"
+	"github.com/platform9/appctl/pkg/constants"
+	"github.com/platform9/appctl/pkg/settings"
+)
+
+// Resets the operation noted for the audit log.
+func resetAudit() {
+	auditEvent, auditApp, auditApps = Event{}, "", 0
+	secretValues = nil
+}
+
+func TestRecordCommand(t *testing.T) {
+	key := useDummyIssuer(t)
+	useTempConfig(t)
+	t.Setenv(constants.TOKENENVVAR, signToken(t, key, dummyClaims(nil)))
+	if err := (&settings.Settings{Audit: true}).Save(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		resetAudit()
+		audit.SetInvocation(audit.Invocation{})
+	})
+
+	recordCases := map[string]struct {
+		// App of the --app-name flag.
+		app             string
+		events          []Event
+		apps            []string
+		err             error
+		expectedEvent   string
+		expectedApp     string
+		expectedOutcome string
+		expectedError   string
+	}{
+		// Refused before reaching the server, like by the policy.
+		"Refused": {app: "web", err: errors.New("App web breaks the deployment policy: no-latest.\n"), expectedEvent: "deploy", expectedApp: "web", expectedOutcome: audit.Failure, expectedError: "App web breaks the deployment policy: no-latest."},
+		"Sent":    {events: []Event{{EventName: "Deploy-App", Status: "Success"}}, apps: []string{"api"}, expectedEvent: "Deploy-App", expectedApp: "api", expectedOutcome: audit.Success},
+		"Failed":  {events: []Event{{EventName: "Deploy-App", Status: "Failure", Error: "quota"}}, apps: []string{"api"}, err: errors.New("quota"), expectedEvent: "Deploy-App", expectedApp: "api", expectedOutcome: audit.Failure, expectedError: "quota"},
+		// Several apps are in the args, not the app.
+		"SeveralApps": {events: []Event{{EventName: "Delete-App", Status: "Failure"}, {EventName: "Delete-App", Status: "Success"}}, apps: []string{"api", "web"}, expectedEvent: "Delete-App", expectedOutcome: audit.Failure},
+		"Masked":      {app: "web", err: errors.New("Not able to deploy app: web.\nError: bad password hunter22"), expectedEvent: "deploy", expectedApp: "web", expectedOutcome: audit.Failure, expectedError: "bad password ********"},
+	}
+	for testName, test := range recordCases {
+		resetAudit()
+		WarnSecrets(map[string]string{"DB_PASSWORD": "hunter22"}, "")
+		audit.SetInvocation(audit.Invocation{Command: "appctl deploy", App: test.app})
+		for i, event := range test.events {
+			noteAudit(event, test.apps[i%len(test.apps)])
+		}
+		before, _ := audit.Read(audit.Query{})
+		RecordCommand("deploy", test.err)
+		entries, _ := audit.Read(audit.Query{})
+		if len(entries) != len(before)+1 {
+			t.Errorf("test case %s: expected an entry, got %d", testName, len(entries)-len(before))
+			continue
+		}
+		entry := entries[len(entries)-1]
+		if entry.Event != test.expectedEvent || entry.App != test.expectedApp || entry.Outcome != test.expectedOutcome || entry.User != "user@appctl.test" {
+			t.Errorf("test case %s: unexpected entry %+v", testName, entry)
+		}
+		if !strings.Contains(entry.Error, test.expectedError) || strings.Contains(entry.Error, "hunter22") {
+			t.Errorf("test case %s: expected error %q, got %q", testName, test.expectedError, entry.Error)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package appManageAPI
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/platform9/appctl/pkg/appAPIs"
+)
+
+// Knative autoscaling annotations of the revision template.
+const (
+	minScaleAnnotation          = "autoscaling.knative.dev/min-scale"
+	maxScaleAnnotation          = "autoscaling.knative.dev/max-scale"
+	concurrencyTargetAnnotation = "autoscaling.knative.dev/target"
+	scaleDownDelayAnnotation    = "autoscaling.knative.dev/scale-down-delay"
+)
+
+// Longest scale down delay Knative accepts.
+const maxScaleDownDelay = time.Hour
+
+// Autoscaling settings of deploy, nil or empty ones are left to the server.
+type Autoscaling struct {
+	// Replicas kept at least, 1 or more avoids cold starts.
+	MinScale *int
+	// Replicas at most, 0 for no limit.
+	MaxScale *int
+	// Concurrent requests per replica the autoscaler aims for.
+	ConcurrencyTarget *int
+	// Concurrent requests a replica accepts at most, 0 for no limit.
+	ContainerConcurrency *int
+	// How long to wait before scaling down, eg. 15m.
+	ScaleDownDelay string
+}
+
+// To validate the autoscaling settings, and set them in the container
+// options as Knative annotations and containerConcurrency.
+func SetAutoscaling(options *appAPIs.ContainerOptions, autoscaling Autoscaling) error {
+	annotations := map[string]string{}
+	if autoscaling.MinScale != nil {
+		if *autoscaling.MinScale < 0 {
+			return fmt.Errorf("Invalid --min-scale %d, must be 0 or more.", *autoscaling.MinScale)
+		}
+		annotations[minScaleAnnotation] = strconv.Itoa(*autoscaling.MinScale)
+	}
+	if autoscaling.MaxScale != nil {
+		if *autoscaling.MaxScale < 0 {
+			return fmt.Errorf("Invalid --max-scale %d, must be 0 or more, 0 for no limit.", *autoscaling.MaxScale)
+		}
+		if autoscaling.MinScale != nil && *autoscaling.MaxScale > 0 && *autoscaling.MinScale > *autoscaling.MaxScale {
+			return fmt.Errorf("--min-scale %d is more than --max-scale %d.", *autoscaling.MinScale, *autoscaling.MaxScale)
+		}
+		annotations[maxScaleAnnotation] = strconv.Itoa(*autoscaling.MaxScale)
+	}
+	if autoscaling.ContainerConcurrency != nil && *autoscaling.ContainerConcurrency < 0 {
+		return fmt.Errorf("Invalid --container-concurrency %d, must be 0 or more, 0 for no limit.", *autoscaling.ContainerConcurrency)
+	}
+	if autoscaling.ConcurrencyTarget != nil {
+		if *autoscaling.ConcurrencyTarget < 1 {
+			return fmt.Errorf("Invalid --concurrency-target %d, must be 1 or more.", *autoscaling.ConcurrencyTarget)
+		}
+		if autoscaling.ContainerConcurrency != nil && *autoscaling.ContainerConcurrency > 0 && *autoscaling.ConcurrencyTarget > *autoscaling.ContainerConcurrency {
+			return fmt.Errorf("--concurrency-target %d is more than --container-concurrency %d.", *autoscaling.ConcurrencyTarget, *autoscaling.ContainerConcurrency)
+		}
+		annotations[concurrencyTargetAnnotation] = strconv.Itoa(*autoscaling.ConcurrencyTarget)
+	}
+	if autoscaling.ScaleDownDelay != "" {
+		delay, err := time.ParseDuration(autoscaling.ScaleDownDelay)
+		if err != nil || delay < 0 || delay > maxScaleDownDelay {
+			return fmt.Errorf("Invalid --scale-down-delay %q, use a duration up to 1h like 30s or 15m.", autoscaling.ScaleDownDelay)
+		}
+		annotations[scaleDownDelayAnnotation] = autoscaling.ScaleDownDelay
+	}
+
+	if len(annotations) > 0 {
+		if options.Annotations == nil {
+			options.Annotations = map[string]string{}
+		}
+		for key, value := range annotations {
+			options.Annotations[key] = value
+		}
+	}
+	options.ContainerConcurrency = autoscaling.ContainerConcurrency
+	return nil
+}
+
+// Rows for the autoscaling settings of the revision template.
+func autoscalingRows(get_app map[string]interface{}) []string {
+	spec, _ := get_app["spec"].(map[string]interface{})
+	template, _ := spec["template"].(map[string]interface{})
+	metadata, _ := template["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	templateSpec, _ := template["spec"].(map[string]interface{})
+	return []string{
+		"Min scale: | " + stringOrNotSet(annotations[minScaleAnnotation]),
+		"Max scale: | " + stringOrNotSet(annotations[maxScaleAnnotation]),
+		"Concurrency target: | " + stringOrNotSet(annotations[concurrencyTargetAnnotation]),
+		"Container concurrency: | " + stringOrNotSet(templateSpec["containerConcurrency"]),
+		"Scale down delay: | " + stringOrNotSet(annotations[scaleDownDelayAnnotation]),
+	}
+}
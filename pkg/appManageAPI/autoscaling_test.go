@@ -0,0 +1,98 @@
+package appManageAPI
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/platform9/appctl/pkg/appAPIs"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestSetAutoscaling(t *testing.T) {
+	autoscalingCases := map[string]struct {
+		autoscaling                  Autoscaling
+		expectedAnnotations          string
+		expectedContainerConcurrency string
+		expectedErr                  string
+	}{
+		"None": {expectedAnnotations: "map[]", expectedContainerConcurrency: "<nil>"},
+		"Scale": {
+			autoscaling:                  Autoscaling{MinScale: intPtr(1), MaxScale: intPtr(5)},
+			expectedAnnotations:          "map[autoscaling.knative.dev/max-scale:5 autoscaling.knative.dev/min-scale:1]",
+			expectedContainerConcurrency: "<nil>",
+		},
+		"NoMaxLimit": {
+			autoscaling:                  Autoscaling{MinScale: intPtr(2), MaxScale: intPtr(0)},
+			expectedAnnotations:          "map[autoscaling.knative.dev/max-scale:0 autoscaling.knative.dev/min-scale:2]",
+			expectedContainerConcurrency: "<nil>",
+		},
+		"Concurrency": {
+			autoscaling:                  Autoscaling{ConcurrencyTarget: intPtr(50), ContainerConcurrency: intPtr(100), ScaleDownDelay: "15m"},
+			expectedAnnotations:          "map[autoscaling.knative.dev/scale-down-delay:15m autoscaling.knative.dev/target:50]",
+			expectedContainerConcurrency: "100",
+		},
+		"UnlimitedContainerConcurrency": {
+			autoscaling:                  Autoscaling{ConcurrencyTarget: intPtr(200), ContainerConcurrency: intPtr(0)},
+			expectedAnnotations:          "map[autoscaling.knative.dev/target:200]",
+			expectedContainerConcurrency: "0",
+		},
+		"NegativeMinScale": {autoscaling: Autoscaling{MinScale: intPtr(-1)}, expectedErr: "Invalid --min-scale"},
+		"MinOverMax":       {autoscaling: Autoscaling{MinScale: intPtr(3), MaxScale: intPtr(2)}, expectedErr: "--min-scale 3 is more than --max-scale 2"},
+		"ZeroTarget":       {autoscaling: Autoscaling{ConcurrencyTarget: intPtr(0)}, expectedErr: "Invalid --concurrency-target"},
+		"TargetOverLimit":  {autoscaling: Autoscaling{ConcurrencyTarget: intPtr(20), ContainerConcurrency: intPtr(10)}, expectedErr: "--concurrency-target 20 is more than --container-concurrency 10"},
+		"NegativeLimit":    {autoscaling: Autoscaling{ContainerConcurrency: intPtr(-5)}, expectedErr: "Invalid --container-concurrency"},
+		"InvalidDelay":     {autoscaling: Autoscaling{ScaleDownDelay: "soon"}, expectedErr: "Invalid --scale-down-delay"},
+		"DelayOverLimit":   {autoscaling: Autoscaling{ScaleDownDelay: "2h"}, expectedErr: "Invalid --scale-down-delay"},
+		"NegativeDelay":    {autoscaling: Autoscaling{ScaleDownDelay: "-1m"}, expectedErr: "Invalid --scale-down-delay"},
+	}
+
+	for testName, test := range autoscalingCases {
+		var options appAPIs.ContainerOptions
+		err := SetAutoscaling(&options, test.autoscaling)
+		if test.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+				t.Errorf("test case %s: expected error %q, got %v", testName, test.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test case %s: failed with error: %v", testName, err)
+			continue
+		}
+		containerConcurrency := "<nil>"
+		if options.ContainerConcurrency != nil {
+			containerConcurrency = fmt.Sprint(*options.ContainerConcurrency)
+		}
+		if fmt.Sprint(options.Annotations) != test.expectedAnnotations || containerConcurrency != test.expectedContainerConcurrency {
+			t.Errorf("test case %s: expected annotations %s and container concurrency %s, got %v and %s",
+				testName, test.expectedAnnotations, test.expectedContainerConcurrency, options.Annotations, containerConcurrency)
+		}
+	}
+}
+
+func TestAutoscalingRows(t *testing.T) {
+	app := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]interface{}{minScaleAnnotation: "1", concurrencyTargetAnnotation: "50"},
+				},
+				"spec": map[string]interface{}{"containerConcurrency": float64(100)},
+			},
+		},
+	}
+	expected := []string{
+		"Min scale: | 1",
+		"Max scale: | " + notSet,
+		"Concurrency target: | 50",
+		"Container concurrency: | 100",
+		"Scale down delay: | " + notSet,
+	}
+	if rows := autoscalingRows(app); fmt.Sprint(rows) != fmt.Sprint(expected) {
+		t.Errorf("expected %q, got %q", expected, rows)
+	}
+}
@@ -0,0 +1,189 @@
+package appManageAPI
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	isconnect "github.com/alimasyhur/is-connect"
+	"github.com/platform9/appctl/pkg/appAPIs"
+	"github.com/platform9/appctl/pkg/constants"
+)
+
+// Result of deleting one app in a bulk delete.
+type DeleteResult struct {
+	Name string
+	Err  error
+}
+
+// To select apps for deletion by label selector and age. An empty selector
+// and zero olderThan select all apps.
+func SelectApps(selector string, olderThan time.Duration) ([]string, error) {
+	requirements, err := parseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	//Check Internet Connectivity
+	if !isconnect.IsOnline() {
+		return nil, fmt.Errorf("Network unreachable. %v\n", constants.InternetConnectivity)
+	}
+
+	// Load the token, from APPCTL_TOKEN or the config file.
+	token, err := loadToken("list apps")
+	if err != nil {
+		return nil, err
+	}
+
+	list_apps, err := appAPIs.ListApps(token)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list apps with error: %v\n", err)
+	}
+
+	var names []string
+	items, _ := list_apps["items"].([]interface{})
+	for _, item := range items {
+		metadata, _ := item.(map[string]interface{})["metadata"].(map[string]interface{})
+		if metadata == nil {
+			continue
+		}
+		labels, _ := metadata["labels"].(map[string]interface{})
+		if !requirements.matches(labels) {
+			continue
+		}
+		if olderThan > 0 {
+			created, err := time.Parse(constants.UTCClusterTimeStamp, fmt.Sprintf("%v", metadata["creationTimestamp"]))
+			if err != nil || now().Sub(created) < olderThan {
+				continue
+			}
+		}
+		names = append(names, fmt.Sprintf("%v", metadata["name"]))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// To delete several apps, with at most parallel deletes at a time.
+// Results are in the same order as names.
+func DeleteApps(names []string, parallel int) ([]DeleteResult, error) {
+	//Check Internet Connectivity
+	if !isconnect.IsOnline() {
+		return nil, fmt.Errorf("Network unreachable. %v\n", constants.InternetConnectivity)
+	}
+
+	// Load the token once, from APPCTL_TOKEN or the config file.
+	token, err := loadToken("delete apps")
+	if err != nil {
+		return nil, err
+	}
+
+	if parallel < 1 {
+		parallel = 1
+	}
+	results := make([]DeleteResult, len(names))
+	slots := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, name string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i] = DeleteResult{Name: name, Err: deleteApp(name, token)}
+		}(i, name)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// A single requirement of a label selector, eg. "tier=web" or "!canary".
+type labelRequirement struct {
+	key      string
+	operator string // one of "=", "!=", "exists", "!exists"
+	value    string
+}
+
+type labelSelector []labelRequirement
+
+// To parse a comma separated label selector, supporting key=value, key==value,
+// key!=value, key and !key, same as kubectl.
+func parseSelector(selector string) (labelSelector, error) {
+	var requirements labelSelector
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		var requirement labelRequirement
+		switch {
+		case strings.Contains(term, "!="):
+			parts := strings.SplitN(term, "!=", 2)
+			requirement = labelRequirement{key: parts[0], operator: "!=", value: parts[1]}
+		case strings.Contains(term, "=="):
+			parts := strings.SplitN(term, "==", 2)
+			requirement = labelRequirement{key: parts[0], operator: "=", value: parts[1]}
+		case strings.Contains(term, "="):
+			parts := strings.SplitN(term, "=", 2)
+			requirement = labelRequirement{key: parts[0], operator: "=", value: parts[1]}
+		case strings.HasPrefix(term, "!"):
+			requirement = labelRequirement{key: strings.TrimPrefix(term, "!"), operator: "!exists"}
+		default:
+			requirement = labelRequirement{key: term, operator: "exists"}
+		}
+		requirement.key = strings.TrimSpace(requirement.key)
+		requirement.value = strings.TrimSpace(requirement.value)
+		if requirement.key == "" || strings.ContainsAny(requirement.key+requirement.value, "=! ") {
+			return nil, fmt.Errorf("Invalid selector %q.", term)
+		}
+		requirements = append(requirements, requirement)
+	}
+	return requirements, nil
+}
+
+// To check if the labels of an app match all requirements of the selector.
+func (s labelSelector) matches(labels map[string]interface{}) bool {
+	for _, requirement := range s {
+		value, found := labels[requirement.key]
+		switch requirement.operator {
+		case "=":
+			if !found || fmt.Sprintf("%v", value) != requirement.value {
+				return false
+			}
+		case "!=":
+			if found && fmt.Sprintf("%v", value) == requirement.value {
+				return false
+			}
+		case "exists":
+			if !found {
+				return false
+			}
+		case "!exists":
+			if found {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// To parse an age like 7d, 2w or any Go duration like 36h or 90m.
+func ParseAge(age string) (time.Duration, error) {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if strings.HasSuffix(age, suffix) {
+			count, err := strconv.Atoi(strings.TrimSuffix(age, suffix))
+			if err != nil || count < 0 {
+				return 0, fmt.Errorf("Invalid age %q, use a number of days like 7d, weeks like 2w or a duration like 36h.", age)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+	duration, err := time.ParseDuration(age)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("Invalid age %q, use a number of days like 7d, weeks like 2w or a duration like 36h.", age)
+	}
+	return duration, nil
+}
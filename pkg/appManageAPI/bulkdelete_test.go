@@ -0,0 +1,182 @@
+package appManageAPI
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/platform9/appctl/pkg/constants"
+)
+
+func TestParseSelector(t *testing.T) {
+	labels := map[string]interface{}{"event": "hackathon", "team": "web"}
+	selectorCases := map[string]struct {
+		selector      string
+		expectedMatch bool
+		expectedErr   bool
+	}{
+		"Empty":         {selector: "", expectedMatch: true},
+		"Equals":        {selector: "event=hackathon", expectedMatch: true},
+		"DoubleEquals":  {selector: "event==hackathon", expectedMatch: true},
+		"EqualsOther":   {selector: "event=demo"},
+		"NotEquals":     {selector: "team!=api", expectedMatch: true},
+		"NotEqualsSame": {selector: "team!=web"},
+		"Exists":        {selector: "team", expectedMatch: true},
+		"NotExists":     {selector: "!keep", expectedMatch: true},
+		"NotExistsSet":  {selector: "!team"},
+		"All":           {selector: "event=hackathon, team!=api,!keep", expectedMatch: true},
+		"OneMismatch":   {selector: "event=hackathon,team=api"},
+		"Invalid":       {selector: "=web", expectedErr: true},
+		"InvalidValue":  {selector: "team=a=b", expectedErr: true},
+	}
+
+	for testName, test := range selectorCases {
+		selector, err := parseSelector(test.selector)
+		if test.expectedErr {
+			if err == nil {
+				t.Errorf("test case %s: expected error for selector %q", testName, test.selector)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test case %s: failed with error: %v", testName, err)
+			continue
+		}
+		if selector.matches(labels) != test.expectedMatch {
+			t.Errorf("test case %s: expected match %v for selector %q", testName, test.expectedMatch, test.selector)
+		}
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	ageCases := map[string]time.Duration{
+		"7d":  7 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"36h": 36 * time.Hour,
+		"90m": 90 * time.Minute,
+	}
+	for age, expected := range ageCases {
+		if duration, err := ParseAge(age); err != nil || duration != expected {
+			t.Errorf("expected %v for %q, got %v with error: %v", expected, age, duration, err)
+		}
+	}
+	for _, age := range []string{"", "d", "-1d", "7days", "week"} {
+		if _, err := ParseAge(age); err == nil {
+			t.Errorf("expected error for %q", age)
+		}
+	}
+}
+
+// Logs in with a valid token through APPCTL_TOKEN, and fakes the connectivity check.
+func useDummyLogin(t *testing.T) {
+	key := useDummyIssuer(t)
+	useTempConfig(t)
+	t.Setenv(constants.TOKENENVVAR, signToken(t, key, dummyClaims(nil)))
+	httpmock.Activate()
+	t.Cleanup(httpmock.DeactivateAndReset)
+	httpmock.RegisterResponder(http.MethodGet, "https://google.com", httpmock.NewStringResponder(200, ""))
+}
+
+func dummyApp(name string, labels map[string]interface{}, age time.Duration) map[string]interface{} {
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":              name,
+			"labels":            labels,
+			"creationTimestamp": time.Now().Add(-age).UTC().Format(constants.UTCClusterTimeStamp),
+		},
+	}
+}
+
+func TestSelectApps(t *testing.T) {
+	useDummyLogin(t)
+	httpmock.RegisterResponder(http.MethodGet, constants.APPURL, func(req *http.Request) (*http.Response, error) {
+		return httpmock.NewJsonResponse(200, map[string]interface{}{
+			"items": []interface{}{
+				dummyApp("hack-old", map[string]interface{}{"event": "hackathon"}, 10*24*time.Hour),
+				dummyApp("hack-new", map[string]interface{}{"event": "hackathon"}, time.Hour),
+				dummyApp("prod", map[string]interface{}{"keep": "true"}, 30*24*time.Hour),
+				dummyApp("demo", nil, 2*time.Hour),
+			},
+		})
+	})
+
+	selectCases := map[string]struct {
+		selector  string
+		olderThan time.Duration
+		expected  string
+	}{
+		"All":                 {expected: "demo hack-new hack-old prod"},
+		"Selector":            {selector: "event=hackathon", expected: "hack-new hack-old"},
+		"OlderThan":           {olderThan: 7 * 24 * time.Hour, expected: "hack-old prod"},
+		"SelectorOlderThan":   {selector: "!keep", olderThan: 90 * time.Minute, expected: "demo hack-old"},
+		"NothingMatches":      {selector: "event=demo", expected: ""},
+		"SelectorNotExists":   {selector: "!event", expected: "demo prod"},
+		"OlderThanEverything": {olderThan: 365 * 24 * time.Hour, expected: ""},
+	}
+	for testName, test := range selectCases {
+		names, err := SelectApps(test.selector, test.olderThan)
+		if err != nil {
+			t.Errorf("test case %s: failed with error: %v", testName, err)
+			continue
+		}
+		if strings.Join(names, " ") != test.expected {
+			t.Errorf("test case %s: expected %q, got %q", testName, test.expected, strings.Join(names, " "))
+		}
+	}
+}
+
+func TestDeleteApps(t *testing.T) {
+	useDummyLogin(t)
+
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	names := []string{"app1", "app2", "missing", "app3", "app4", "app5", "app6"}
+	for _, name := range names {
+		name := name
+		httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("%s/%s", constants.APPURL, name), func(req *http.Request) (*http.Response, error) {
+			if name == "missing" {
+				return httpmock.NewStringResponse(400, ""), nil
+			}
+			return httpmock.NewJsonResponse(200, dummyApp(name, nil, time.Hour))
+		})
+		httpmock.RegisterResponder(http.MethodDelete, fmt.Sprintf("%s/%s", constants.APPURL, name), func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mu.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			return httpmock.NewStringResponse(200, ""), nil
+		})
+	}
+
+	results, err := DeleteApps(names, 2)
+	if err != nil {
+		t.Fatalf("failed with error: %v", err)
+	}
+	if len(results) != len(names) {
+		t.Fatalf("expected %d results, got %d", len(names), len(results))
+	}
+	for i, result := range results {
+		if result.Name != names[i] {
+			t.Errorf("expected result %d for %s, got %s", i, names[i], result.Name)
+		}
+		if (result.Err != nil) != (result.Name == "missing") {
+			t.Errorf("unexpected result for %s: %v", result.Name, result.Err)
+		}
+	}
+	if maxInFlight > 2 {
+		t.Errorf("expected at most 2 deletes at a time, got %d", maxInFlight)
+	}
+	if httpmock.GetCallCountInfo()[fmt.Sprintf("DELETE %s/missing", constants.APPURL)] != 0 {
+		t.Errorf("app that doesn't exist should not be deleted")
+	}
+}
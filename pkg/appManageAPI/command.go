@@ -0,0 +1,51 @@
+package appManageAPI
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/platform9/appctl/pkg/appAPIs"
+)
+
+// To set the entrypoint and arguments of the container, instead of the ones
+// of the image. Arguments without a command are passed to the entrypoint of
+// the image.
+func SetCommand(options *appAPIs.ContainerOptions, command string, args []string) error {
+	if command != "" {
+		// The command isn't run by a shell, so it can't carry its arguments.
+		if strings.ContainsAny(command, " \t\n") {
+			return fmt.Errorf("Invalid --command %q, pass its arguments with --arg, eg. --command %v --arg %v.",
+				command, strings.Fields(command)[0], strings.Join(strings.Fields(command)[1:], " --arg "))
+		}
+		options.Command = []string{command}
+	}
+	options.Args = args
+	return nil
+}
+
+// Rows for the command and arguments of the container.
+func commandRows(container map[string]interface{}) []string {
+	return []string{
+		"Command: | " + describeArgs(container["command"]),
+		"Args: | " + describeArgs(container["args"]),
+	}
+}
+
+// To join arguments as they would be typed in a shell, quoting the ones with
+// spaces.
+func describeArgs(value interface{}) string {
+	args, _ := value.([]interface{})
+	if len(args) == 0 {
+		return "<image default>"
+	}
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		arg := stringOf(arg)
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'") {
+			arg = strconv.Quote(arg)
+		}
+		quoted = append(quoted, arg)
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,59 @@
+package appManageAPI
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/platform9/appctl/pkg/appAPIs"
+)
+
+func TestSetCommand(t *testing.T) {
+	commandCases := map[string]struct {
+		command         string
+		args            []string
+		expectedCommand string
+		expectedArgs    string
+		expectedErr     string
+	}{
+		"None":        {expectedCommand: "[]", expectedArgs: "[]"},
+		"Command":     {command: "/app/worker", expectedCommand: "[/app/worker]", expectedArgs: "[]"},
+		"CommandArgs": {command: "python", args: []string{"-m", "worker"}, expectedCommand: "[python]", expectedArgs: "[-m worker]"},
+		"ArgsOnly":    {args: []string{"--mode=worker"}, expectedCommand: "[]", expectedArgs: "[--mode=worker]"},
+		"CommandWithArgs": {
+			command:     "python -m worker",
+			expectedErr: "pass its arguments with --arg, eg. --command python --arg -m --arg worker",
+		},
+	}
+	for testName, test := range commandCases {
+		var options appAPIs.ContainerOptions
+		err := SetCommand(&options, test.command, test.args)
+		if test.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+				t.Errorf("test case %s: expected error %q, got %v", testName, test.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test case %s: failed with error: %v", testName, err)
+			continue
+		}
+		if fmt.Sprint(options.Command) != test.expectedCommand || fmt.Sprint(options.Args) != test.expectedArgs {
+			t.Errorf("test case %s: expected command %s and args %s, got %v and %v",
+				testName, test.expectedCommand, test.expectedArgs, options.Command, options.Args)
+		}
+	}
+}
+
+func TestCommandRows(t *testing.T) {
+	container := map[string]interface{}{
+		"args": []interface{}{"--greeting", "hello world", ""},
+	}
+	expected := []string{
+		"Command: | <image default>",
+		`Args: | --greeting "hello world" ""`,
+	}
+	if rows := commandRows(container); fmt.Sprint(rows) != fmt.Sprint(expected) {
+		t.Errorf("expected %q, got %q", expected, rows)
+	}
+}
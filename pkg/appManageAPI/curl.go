@@ -0,0 +1,82 @@
+package appManageAPI
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	isconnect "github.com/alimasyhur/is-connect"
+	"github.com/platform9/appctl/pkg/appAPIs"
+	"github.com/platform9/appctl/pkg/constants"
+	"github.com/platform9/appctl/pkg/grpchealth"
+)
+
+// To send a GET request to a path of an app, and print the response.
+func CurlApp(name string, path string) error {
+	appURL, err := appURLByName(name, "call app")
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), constants.CURLTIMEOUT*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(appURL, "/")+"/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return fmt.Errorf("Invalid path %q: %v\n", path, err)
+	}
+	resp, err := (&http.Client{}).Do(req)
+	if err != nil {
+		return fmt.Errorf("Failed to call app %v with error: %v\n", name, err)
+	}
+	defer resp.Body.Close()
+	fmt.Printf("%v %v\n", resp.Proto, resp.Status)
+	io.Copy(os.Stdout, resp.Body)
+	return nil
+}
+
+// To check the health of a gRPC app with grpc.health.v1, for the whole
+// server if service is empty. Fails unless the service is serving.
+func CheckGRPCHealth(name string, service string) error {
+	appURL, err := appURLByName(name, "check app health")
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), constants.CURLTIMEOUT*time.Second)
+	defer cancel()
+	status, err := grpchealth.Check(ctx, &http.Client{}, appURL, service)
+	if err != nil {
+		return fmt.Errorf("Failed to check health of app %v with error: %v\n", name, err)
+	}
+	fmt.Printf("Status: %v\n", status)
+	if status != grpchealth.Serving {
+		return fmt.Errorf("App %v is not serving.\n", name)
+	}
+	return nil
+}
+
+// URL of an app, by its name.
+func appURLByName(name string, action string) (string, error) {
+	//Check Internet Connectivity
+	if !isconnect.IsOnline() {
+		return "", fmt.Errorf("Network unreachable. %v\n", constants.InternetConnectivity)
+	}
+
+	// Load the token, from APPCTL_TOKEN or the config file.
+	token, err := loadToken(action)
+	if err != nil {
+		return "", err
+	}
+
+	get_app, err := appAPIs.GetAppByName(name, token)
+	if err != nil {
+		return "", fmt.Errorf("Failed to get app information with error: %v\nCheck 'appctl list' for more information on apps running.\n", err)
+	}
+	status, _ := get_app["status"].(map[string]interface{})
+	if status["url"] == nil {
+		return "", fmt.Errorf("App %v has no URL yet, check its status with `appctl describe -n %v -o text`.\n", name, name)
+	}
+	return stringOf(status["url"]), nil
+}
@@ -0,0 +1,86 @@
+package appManageAPI
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ryanuber/columnize"
+)
+
+// Output formats of describe.
+const (
+	DescribeJSON = "json"
+	DescribeText = "text"
+)
+
+// Shown for settings the app doesn't set, which the server defaults.
+const notSet = "<server default>"
+
+// To print a summary of an app, with the effective settings of its container.
+func printAppDetails(get_app map[string]interface{}) {
+	info, _ := fetchAppInfo(get_app)
+	container := appContainer(get_app)
+	image, digest := appImage(get_app)
+
+	rows := []string{
+		"Name: | " + info.Name,
+		"URL: | " + info.URL,
+		"Image: | " + image,
+		"Digest: | " + valueOrNone(digest),
+		"Labels: | " + describeLabels(get_app),
+		"Registry: | " + valueOrNone(strings.Join(appRegistries(get_app), ", ")),
+		"Port: | " + valueOrNotSet(info.Port),
+		"Protocol: | " + getProtocol(container),
+		"Ready: | " + info.ReadyStatus,
+		"Age: | " + appAge(info.CreationTime),
+	}
+	if info.Reason != "" {
+		rows = append(rows, "Reason: | "+info.Reason)
+	}
+	rows = append(rows, commandRows(container)...)
+	rows = append(rows, "Env: | "+describeEnv(container))
+	rows = append(rows, resourceRows(container)...)
+	rows = append(rows, probeRows(container)...)
+	rows = append(rows, autoscalingRows(get_app)...)
+	fmt.Println(columnize.SimpleFormat(rows))
+}
+
+// Rows for the requests and limits of the container.
+func resourceRows(container map[string]interface{}) []string {
+	resources, _ := container["resources"].(map[string]interface{})
+	requests, _ := resources["requests"].(map[string]interface{})
+	limits, _ := resources["limits"].(map[string]interface{})
+	return []string{
+		"CPU request: | " + stringOrNotSet(requests["cpu"]),
+		"CPU limit: | " + stringOrNotSet(limits["cpu"]),
+		"Memory request: | " + stringOrNotSet(requests["memory"]),
+		"Memory limit: | " + stringOrNotSet(limits["memory"]),
+	}
+}
+
+// The app container, from the spec of the revision template.
+func appContainer(get_app map[string]interface{}) map[string]interface{} {
+	spec, _ := get_app["spec"].(map[string]interface{})
+	template, _ := spec["template"].(map[string]interface{})
+	templateSpec, _ := template["spec"].(map[string]interface{})
+	containers, _ := templateSpec["containers"].([]interface{})
+	if len(containers) == 0 {
+		return nil
+	}
+	container, _ := containers[0].(map[string]interface{})
+	return container
+}
+
+func stringOrNotSet(value interface{}) string {
+	if value == nil {
+		return notSet
+	}
+	return valueOrNotSet(fmt.Sprintf("%v", value))
+}
+
+func valueOrNotSet(value string) string {
+	if value == "" {
+		return notSet
+	}
+	return value
+}
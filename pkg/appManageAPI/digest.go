@@ -0,0 +1,165 @@
+package appManageAPI
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	isconnect "github.com/alimasyhur/is-connect"
+	"github.com/platform9/appctl/pkg/appAPIs"
+	"github.com/platform9/appctl/pkg/constants"
+	"github.com/platform9/appctl/pkg/registry"
+	"github.com/ryanuber/columnize"
+)
+
+// Annotation of the revision with the image as given to deploy, when the
+// image sent is pinned to the digest its tag pointed to.
+const imageTagAnnotation = "appctl.platform9.io/image"
+
+// To pin the image to a digest, so the app keeps running the same build if
+// the tag is pushed over. The image as given is kept in an annotation.
+// Returns the pinned image, or the image as is if it has a digest already.
+func PinImage(options *appAPIs.ContainerOptions, image string, digest string) string {
+	if digest == "" || strings.Contains(image, "@") {
+		return image
+	}
+	if options.Annotations == nil {
+		options.Annotations = map[string]string{}
+	}
+	options.Annotations[imageTagAnnotation] = image
+	return imageRepository(image) + "@" + digest
+}
+
+// Image without its tag.
+func imageRepository(image string) string {
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i]
+	}
+	return image
+}
+
+// Image the app was deployed from, as given to deploy, and the digest it is
+// pinned to. The digest is empty if the app isn't pinned.
+func appImage(get_app map[string]interface{}) (string, string) {
+	container := appContainer(get_app)
+	image := stringOf(container["image"])
+	spec, _ := get_app["spec"].(map[string]interface{})
+	template, _ := spec["template"].(map[string]interface{})
+	metadata, _ := template["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+
+	var digest string
+	if i := strings.Index(image, "@"); i >= 0 {
+		digest = image[i+1:]
+	}
+	if tag := stringOf(annotations[imageTagAnnotation]); tag != "" {
+		return tag, digest
+	}
+	return image, digest
+}
+
+// To show the image of an app in a table, with the start of its digest.
+func displayImage(get_app map[string]interface{}) string {
+	image, digest := appImage(get_app)
+	if digest == "" || strings.Contains(image, "@") {
+		return image
+	}
+	return fmt.Sprintf("%v (%v)", image, shortDigest(digest))
+}
+
+// The algorithm and first 12 hex digits of a digest, like docker shows.
+func shortDigest(digest string) string {
+	if len(digest) > len("sha256:")+12 {
+		return digest[:len("sha256:")+12]
+	}
+	return digest
+}
+
+// To show the status of apps, all of them if name is empty. With checkDrift,
+// the tag each app was deployed from is resolved again, to flag the apps
+// whose tag now points at another digest. Returns an error if any drifted.
+func AppStatus(name string, checkDrift bool, username string, password string) error {
+	//Check Internet Connectivity
+	if !isconnect.IsOnline() {
+		return fmt.Errorf("Network unreachable. %v\n", constants.InternetConnectivity)
+	}
+
+	// Load the token, from APPCTL_TOKEN or the config file.
+	token, err := loadToken("get status")
+	if err != nil {
+		return err
+	}
+
+	var apps []map[string]interface{}
+	if name != "" {
+		get_app, err := appAPIs.GetAppByName(name, token)
+		if err != nil {
+			return fmt.Errorf("Failed to get app information with error: %v\nCheck 'appctl list' for more information on apps running.\n", err)
+		}
+		apps = append(apps, get_app)
+	} else {
+		list_apps, err := appAPIs.ListApps(token)
+		if err != nil {
+			return fmt.Errorf("Failed to list apps with error: %v\n", err)
+		}
+		items, _ := list_apps["items"].([]interface{})
+		for _, item := range items {
+			if app, ok := item.(map[string]interface{}); ok {
+				apps = append(apps, app)
+			}
+		}
+	}
+	if len(apps) == 0 {
+		fmt.Printf("No apps found.\n")
+		return nil
+	}
+
+	output := []string{"NAME | IMAGE | DIGEST | READY"}
+	if checkDrift {
+		output[0] += " | TAG DIGEST | DRIFT"
+	}
+	drifted := 0
+	for _, get_app := range apps {
+		info, _ := fetchAppInfo(get_app)
+		image, digest := appImage(get_app)
+		row := fmt.Sprintf("%v | %v | %v | %v", info.Name, image, valueOrNone(shortDigest(digest)), info.ReadyStatus)
+		if checkDrift {
+			current, drift := checkImageDrift(image, digest, username, password)
+			if drift == "yes" {
+				drifted++
+			}
+			row += fmt.Sprintf(" | %v | %v", current, drift)
+		}
+		output = append(output, row)
+	}
+	fmt.Println(columnize.SimpleFormat(output))
+	if drifted > 0 {
+		return fmt.Errorf("%d of %d apps run another digest than their tag points to now, deploy them again to update them.\n", drifted, len(apps))
+	}
+	return nil
+}
+
+// To resolve the tag of an image again, returning the digest it points to
+// now, and if it differs from the digest deployed.
+func checkImageDrift(image string, digest string, username string, password string) (string, string) {
+	if digest == "" {
+		return "-", "not pinned"
+	}
+	ref, err := registry.ParseReference(image)
+	if err != nil || ref.Digest != "" {
+		// Deployed by digest, there is no tag to drift.
+		return "-", "no"
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), constants.IMAGECHECKTIMEOUT*time.Second)
+	defer cancel()
+	client := registry.Client{Username: username, Password: password}
+	manifest, err := client.Manifest(ctx, ref, ref.Tag)
+	if err != nil {
+		return "-", fmt.Sprintf("unknown: %v", err)
+	}
+	if manifest.Digest != digest {
+		return shortDigest(manifest.Digest), "yes"
+	}
+	return shortDigest(manifest.Digest), "no"
+}
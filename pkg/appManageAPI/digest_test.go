@@ -0,0 +1,109 @@
+package appManageAPI
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/platform9/appctl/pkg/appAPIs"
+)
+
+const (
+	deployedDigest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	pushedDigest   = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+func TestPinImage(t *testing.T) {
+	pinCases := map[string]struct {
+		image         string
+		digest        string
+		expectedImage string
+		expectedTag   string
+	}{
+		"Tag":          {image: "ghcr.io/org/app:v1", digest: deployedDigest, expectedImage: "ghcr.io/org/app@" + deployedDigest, expectedTag: "ghcr.io/org/app:v1"},
+		"NoTag":        {image: "org/app", digest: deployedDigest, expectedImage: "org/app@" + deployedDigest, expectedTag: "org/app"},
+		"RegistryPort": {image: "localhost:5000/app:v1", digest: deployedDigest, expectedImage: "localhost:5000/app@" + deployedDigest, expectedTag: "localhost:5000/app:v1"},
+		"Digest":       {image: "org/app@" + deployedDigest, digest: deployedDigest, expectedImage: "org/app@" + deployedDigest},
+		"NotResolved":  {image: "org/app:v1", expectedImage: "org/app:v1"},
+	}
+	for testName, test := range pinCases {
+		options := appAPIs.ContainerOptions{}
+		image := PinImage(&options, test.image, test.digest)
+		if image != test.expectedImage {
+			t.Errorf("test case %s: expected image %q, got %q", testName, test.expectedImage, image)
+		}
+		if tag := options.Annotations[imageTagAnnotation]; tag != test.expectedTag {
+			t.Errorf("test case %s: expected annotation %q, got %q", testName, test.expectedTag, tag)
+		}
+	}
+}
+
+// An app as the server returns it, pinned to digest if tag is set.
+func dummyPinnedApp(image string, tag string) map[string]interface{} {
+	template := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{"image": image}},
+		},
+	}
+	if tag != "" {
+		template["metadata"] = map[string]interface{}{
+			"annotations": map[string]interface{}{imageTagAnnotation: tag},
+		}
+	}
+	return map[string]interface{}{"spec": map[string]interface{}{"template": template}}
+}
+
+func TestDisplayImage(t *testing.T) {
+	displayCases := map[string]struct {
+		app      map[string]interface{}
+		expected string
+	}{
+		"Pinned":   {app: dummyPinnedApp("org/app@"+deployedDigest, "org/app:v1"), expected: "org/app:v1 (sha256:111111111111)"},
+		"Tag":      {app: dummyPinnedApp("org/app:v1", ""), expected: "org/app:v1"},
+		"ByDigest": {app: dummyPinnedApp("org/app@"+deployedDigest, ""), expected: "org/app@" + deployedDigest},
+	}
+	for testName, test := range displayCases {
+		if image := displayImage(test.app); image != test.expected {
+			t.Errorf("test case %s: expected %q, got %q", testName, test.expected, image)
+		}
+	}
+}
+
+func TestCheckImageDrift(t *testing.T) {
+	// A registry where v1 was pushed over since the deploy, and v2 wasn't.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+		switch r.URL.Path {
+		case "/v2/org/app/manifests/v1":
+			w.Header().Set("Docker-Content-Digest", pushedDigest)
+		case "/v2/org/app/manifests/v2":
+			w.Header().Set("Docker-Content-Digest", deployedDigest)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[{"code":"MANIFEST_UNKNOWN"}]}`))
+			return
+		}
+		w.Write([]byte(`{"schemaVersion":2}`))
+	}))
+	defer server.Close()
+	repository := strings.TrimPrefix(server.URL, "http://") + "/org/app"
+
+	driftCases := map[string]struct {
+		image    string
+		digest   string
+		expected string
+	}{
+		"Drifted":    {image: repository + ":v1", digest: deployedDigest, expected: "yes"},
+		"Current":    {image: repository + ":v2", digest: deployedDigest, expected: "no"},
+		"NotPinned":  {image: repository + ":v1", expected: "not pinned"},
+		"ByDigest":   {image: repository + "@" + deployedDigest, digest: deployedDigest, expected: "no"},
+		"MissingTag": {image: repository + ":v3", digest: deployedDigest, expected: "unknown"},
+	}
+	for testName, test := range driftCases {
+		_, drift := checkImageDrift(test.image, test.digest, "", "")
+		if !strings.HasPrefix(drift, test.expected) {
+			t.Errorf("test case %s: expected drift %q, got %q", testName, test.expected, drift)
+		}
+	}
+}
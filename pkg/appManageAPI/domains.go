@@ -0,0 +1,208 @@
+package appManageAPI
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	isconnect "github.com/alimasyhur/is-connect"
+	"github.com/briandowns/spinner"
+	"github.com/platform9/appctl/pkg/appAPIs"
+	"github.com/platform9/appctl/pkg/constants"
+	"github.com/ryanuber/columnize"
+)
+
+// Valid custom domain, a lowercase DNS name with at least two labels.
+var domainRegex = regexp.MustCompile(`^([a-z0-9]([-a-z0-9]*[a-z0-9])?\.)+[a-z]([-a-z0-9]*[a-z0-9])?$`)
+
+// To check a custom domain, returning it in lowercase.
+func ValidateDomain(domain string) (string, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if len(domain) > 253 || !domainRegex.MatchString(domain) {
+		return "", fmt.Errorf("Invalid domain %q, use a DNS name like app.example.com.", domain)
+	}
+	return domain, nil
+}
+
+// To map a custom domain to an app. Prints the DNS record to create, then
+// waits for the domain and its certificate to be ready, unless wait is false.
+func AddDomain(name string, domain string, wait bool) error {
+	//Check Internet Connectivity
+	if !isconnect.IsOnline() {
+		return fmt.Errorf("Network unreachable. %v\n", constants.InternetConnectivity)
+	}
+
+	// Load the token, from APPCTL_TOKEN or the config file.
+	token, err := loadToken("add domain")
+	if err != nil {
+		return err
+	}
+
+	get_app, err := appAPIs.GetAppByName(name, token)
+	if err != nil {
+		return fmt.Errorf("Failed to get app information with error: %v\nCheck 'appctl list' for more information on apps running.\n", err)
+	}
+
+	event := Event{EventName: "Add-Domain", Data: []constants.ListAppInfo{{Name: name}}}
+	_, err = appAPIs.CreateDomainMapping(name, domain, token)
+	if err != nil {
+		//Event is Failure.
+		event.Status = "Failure"
+		event.Error = err.Error()
+		send(event, nil)
+		return fmt.Errorf("Failed to add domain %v with error: %v\n", domain, err)
+	}
+	event.Status = "Success"
+	send(event, nil)
+
+	fmt.Printf("Domain %v added to app %v.\n\n", domain, name)
+	printDNSRecords(domain, appHost(get_app))
+	if !wait {
+		fmt.Printf("\nCheck the status of the domain by running command `appctl domain list -n %v`.\n", name)
+		return nil
+	}
+
+	s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
+	s.Color("red")
+	s.Suffix = " Waiting for the domain and its certificate to be ready, once the DNS record is created.."
+	s.Start()
+	mapping, ready, failure := pollUntilReady(
+		func() (map[string]interface{}, error) {
+			return appAPIs.GetDomainMapping(name, domain, token)
+		},
+		domainReady,
+		constants.DOMAINPOLLATTEMPTS,
+		constants.DOMAINPOLLINTERVAL*time.Second,
+	)
+	s.Stop()
+	if failure != "" {
+		return fmt.Errorf("\nDomain %v is not ready: %v\n", domain, failure)
+	}
+	if !ready {
+		fmt.Printf("\nDomain %v is not ready yet, DNS changes and certificates can take a while.\nCheck latest status by running command `appctl domain list -n %v`.\n", domain, name)
+		return nil
+	}
+	fmt.Printf("\nDomain %v is ready, app %v can be accessed at URL: %v\n", domain, name, domainURL(mapping, domain))
+	return nil
+}
+
+// To list the custom domains of an app.
+func ListDomains(name string) error {
+	//Check Internet Connectivity
+	if !isconnect.IsOnline() {
+		return fmt.Errorf("Network unreachable. %v\n", constants.InternetConnectivity)
+	}
+
+	// Load the token, from APPCTL_TOKEN or the config file.
+	token, err := loadToken("list domains")
+	if err != nil {
+		return err
+	}
+
+	mappings, err := appAPIs.ListDomainMappings(name, token)
+	if err != nil {
+		return fmt.Errorf("Failed to list domains with error: %v\n", err)
+	}
+	items, _ := mappings["items"].([]interface{})
+	if len(items) == 0 {
+		fmt.Printf("No domains for app %v, add one by running command `appctl domain add -n %v <domain>`.\n", name, name)
+		return nil
+	}
+
+	output := []string{"DOMAIN | URL | READY | CERTIFICATE | REASON"}
+	for _, item := range items {
+		mapping, _ := item.(map[string]interface{})
+		metadata, _ := mapping["metadata"].(map[string]interface{})
+		domain := stringOf(metadata["name"])
+		ready, reason, message := conditionStatus(mapping, "Ready")
+		certificate, _, _ := conditionStatus(mapping, "CertificateProvisioned")
+		if message != "" {
+			reason = fmt.Sprintf("%v: %v", reason, message)
+		}
+		output = append(output, fmt.Sprintf("%v | %v | %v | %v | %v", domain, domainURL(mapping, domain), ready, certificate, reason))
+	}
+	fmt.Println(columnize.SimpleFormat(output))
+	return nil
+}
+
+// To remove a custom domain of an app.
+func RemoveDomain(name string, domain string) error {
+	//Check Internet Connectivity
+	if !isconnect.IsOnline() {
+		return fmt.Errorf("Network unreachable. %v\n", constants.InternetConnectivity)
+	}
+
+	// Load the token, from APPCTL_TOKEN or the config file.
+	token, err := loadToken("remove domain")
+	if err != nil {
+		return err
+	}
+
+	event := Event{EventName: "Remove-Domain", Data: []constants.ListAppInfo{{Name: name}}}
+	if err := appAPIs.DeleteDomainMapping(name, domain, token); err != nil {
+		//Event is Failure.
+		event.Status = "Failure"
+		event.Error = err.Error()
+		send(event, nil)
+		return fmt.Errorf("Failed to remove domain %v with error: %v\n", domain, err)
+	}
+	event.Status = "Success"
+	send(event, nil)
+	fmt.Printf("Domain %v removed from app %v. The DNS record for it can be deleted.\n", domain, name)
+	return nil
+}
+
+// To print the DNS record pointing the domain to the app.
+func printDNSRecords(domain string, target string) {
+	fmt.Print(dnsRecords(domain, target))
+}
+
+// Instructions to point the domain to the app, target is the host of the
+// app, empty if it has no URL yet.
+func dnsRecords(domain string, target string) string {
+	if target == "" {
+		return fmt.Sprintf("App has no URL yet. Once `appctl describe` shows it, create a CNAME record for %v. pointing to the host of the URL with your DNS provider.\n", domain)
+	}
+	// Apex domains can't have a CNAME record, they can't be told apart from
+	// the name alone, like example.co.uk and app.example.com.
+	return fmt.Sprintf("Create the following DNS record with your DNS provider:\n\n%v\n\nIf %v is an apex domain and your DNS provider doesn't allow a CNAME for it, use an ALIAS or ANAME record.\n",
+		columnize.SimpleFormat([]string{
+			"TYPE | NAME | VALUE",
+			fmt.Sprintf("CNAME | %v. | %v.", domain, target),
+		}), domain)
+}
+
+// Host of the platform generated URL of the app.
+func appHost(get_app map[string]interface{}) string {
+	status, _ := get_app["status"].(map[string]interface{})
+	parsed, err := url.Parse(stringOf(status["url"]))
+	if err != nil {
+		return ""
+	}
+	return parsed.Host
+}
+
+// URL of a domain mapping, or the https URL of the domain if it has none yet.
+func domainURL(mapping map[string]interface{}, domain string) string {
+	status, _ := mapping["status"].(map[string]interface{})
+	if status["url"] != nil {
+		return stringOf(status["url"])
+	}
+	return "https://" + domain
+}
+
+// Check if the domain mapping and its certificate are ready, or why the
+// domain can't be used.
+func domainReady(mapping map[string]interface{}) (bool, string) {
+	// These don't recover by waiting, eg. a domain mapped by another user.
+	for _, conditionType := range []string{"DomainClaimed", "ReferenceResolved"} {
+		if status, reason, message := conditionStatus(mapping, conditionType); status == "False" {
+			return false, fmt.Sprintf("%v %v", reason, message)
+		}
+	}
+	ready, _, _ := conditionStatus(mapping, "Ready")
+	certificate, _, _ := conditionStatus(mapping, "CertificateProvisioned")
+	return ready == "True" && (certificate == "" || certificate == "True"), ""
+}
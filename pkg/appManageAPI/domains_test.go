@@ -0,0 +1,193 @@
+package appManageAPI
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/platform9/appctl/pkg/constants"
+)
+
+func dummyDomainMapping(conditions ...map[string]interface{}) map[string]interface{} {
+	items := []interface{}{}
+	for _, condition := range conditions {
+		items = append(items, condition)
+	}
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "app.example.com"},
+		"status":   map[string]interface{}{"conditions": items},
+	}
+}
+
+func condition(conditionType string, status string) map[string]interface{} {
+	return map[string]interface{}{"type": conditionType, "status": status, "reason": conditionType + status}
+}
+
+func TestValidateDomain(t *testing.T) {
+	domainCases := map[string]struct {
+		domain   string
+		expected string
+	}{
+		"Subdomain":   {domain: "app.example.com", expected: "app.example.com"},
+		"Apex":        {domain: "example.com", expected: "example.com"},
+		"Uppercase":   {domain: "App.Example.COM.", expected: "app.example.com"},
+		"SingleLabel": {domain: "localhost"},
+		"Scheme":      {domain: "https://app.example.com"},
+		"Underscore":  {domain: "my_app.example.com"},
+		"Wildcard":    {domain: "*.example.com"},
+	}
+	for testName, test := range domainCases {
+		domain, err := ValidateDomain(test.domain)
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("test case %s: expected an error, got %q", testName, domain)
+			}
+			continue
+		}
+		if err != nil || domain != test.expected {
+			t.Errorf("test case %s: expected %q, got %q and error %v", testName, test.expected, domain, err)
+		}
+	}
+}
+
+func TestDomainReady(t *testing.T) {
+	readyCases := map[string]struct {
+		mapping         map[string]interface{}
+		expectedReady   bool
+		expectedFailure string
+	}{
+		"NoStatus":           {mapping: map[string]interface{}{}},
+		"CertificatePending": {mapping: dummyDomainMapping(condition("Ready", "Unknown"), condition("CertificateProvisioned", "Unknown"))},
+		"ReadyCertificatePending": {
+			mapping: dummyDomainMapping(condition("Ready", "True"), condition("CertificateProvisioned", "Unknown")),
+		},
+		"Ready": {
+			mapping:       dummyDomainMapping(condition("Ready", "True"), condition("CertificateProvisioned", "True")),
+			expectedReady: true,
+		},
+		"ReadyWithoutCertificate": {mapping: dummyDomainMapping(condition("Ready", "True")), expectedReady: true},
+		"NotClaimed": {
+			mapping:         dummyDomainMapping(condition("Ready", "False"), condition("DomainClaimed", "False")),
+			expectedFailure: "DomainClaimedFalse",
+		},
+		"NotResolved": {
+			mapping:         dummyDomainMapping(condition("ReferenceResolved", "False")),
+			expectedFailure: "ReferenceResolvedFalse",
+		},
+	}
+	for testName, test := range readyCases {
+		ready, failure := domainReady(test.mapping)
+		if ready != test.expectedReady || !strings.Contains(failure, test.expectedFailure) || (test.expectedFailure == "") != (failure == "") {
+			t.Errorf("test case %s: expected %v and %q, got %v and %q", testName, test.expectedReady, test.expectedFailure, ready, failure)
+		}
+	}
+}
+
+func TestPollUntilReady(t *testing.T) {
+	savedSleep := sleep
+	t.Cleanup(func() { sleep = savedSleep })
+	var slept []time.Duration
+	sleep = func(d time.Duration) { slept = append(slept, d) }
+
+	fetches := 0
+	fetch := func() (map[string]interface{}, error) {
+		fetches++
+		if fetches == 1 {
+			return nil, fmt.Errorf("connection reset")
+		}
+		return map[string]interface{}{"fetches": fetches}, nil
+	}
+	readyAt := func(n int) func(map[string]interface{}) (bool, string) {
+		return func(resource map[string]interface{}) (bool, string) {
+			return resource["fetches"] == n, ""
+		}
+	}
+
+	resource, ready, failure := pollUntilReady(fetch, readyAt(3), 5, time.Second)
+	if !ready || failure != "" || resource["fetches"] != 3 || len(slept) != 2 {
+		t.Errorf("expected ready on the 3rd fetch after 2 sleeps, got %v, %v, %q and %d sleeps", resource, ready, failure, len(slept))
+	}
+
+	fetches, slept = 0, nil
+	resource, ready, failure = pollUntilReady(fetch, readyAt(10), 4, time.Second)
+	if ready || failure != "" || resource["fetches"] != 4 || len(slept) != 3 {
+		t.Errorf("expected not ready after 4 fetches and 3 sleeps, got %v, %v, %q and %d sleeps", resource, ready, failure, len(slept))
+	}
+
+	fetches, slept = 0, nil
+	_, ready, failure = pollUntilReady(fetch, func(map[string]interface{}) (bool, string) { return false, "failed" }, 4, time.Second)
+	if ready || failure != "failed" || fetches != 2 {
+		t.Errorf("expected to stop on the first failure, got %v, %q after %d fetches", ready, failure, fetches)
+	}
+}
+
+func TestAddDomain(t *testing.T) {
+	useDummyLogin(t)
+	savedSleep := sleep
+	t.Cleanup(func() { sleep = savedSleep })
+	sleep = func(time.Duration) {}
+
+	appURL := fmt.Sprintf("%s/%s", constants.APPURL, "app")
+	domainURL := appURL + "/domains/app.example.com"
+	httpmock.RegisterResponder(http.MethodGet, appURL, httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{
+		"status": map[string]interface{}{"url": "https://app.user.apps.example.net"},
+	}))
+	var created string
+	httpmock.RegisterResponder(http.MethodPost, appURL+"/domains", func(req *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(req.Body)
+		created = string(body)
+		return httpmock.NewStringResponse(201, ""), nil
+	})
+	gets := 0
+	httpmock.RegisterResponder(http.MethodGet, domainURL, func(req *http.Request) (*http.Response, error) {
+		gets++
+		if gets < 3 {
+			return httpmock.NewJsonResponse(200, dummyDomainMapping(condition("Ready", "Unknown"), condition("CertificateProvisioned", "Unknown")))
+		}
+		return httpmock.NewJsonResponse(200, dummyDomainMapping(condition("Ready", "True"), condition("CertificateProvisioned", "True")))
+	})
+
+	if err := AddDomain("app", "app.example.com", true); err != nil {
+		t.Fatalf("failed with error: %v", err)
+	}
+	if created != `{"domain":"app.example.com"}` {
+		t.Errorf("expected the domain in the payload, got %q", created)
+	}
+	if gets != 3 {
+		t.Errorf("expected to poll until ready on the 3rd get, got %d gets", gets)
+	}
+
+	httpmock.RegisterResponder(http.MethodGet, domainURL, httpmock.NewJsonResponderOrPanic(200,
+		dummyDomainMapping(condition("Ready", "False"), condition("DomainClaimed", "False"))))
+	if err := AddDomain("app", "app.example.com", true); err == nil || !strings.Contains(err.Error(), "DomainClaimedFalse") {
+		t.Errorf("expected the domain claim to fail, got %v", err)
+	}
+}
+
+func TestDNSRecords(t *testing.T) {
+	dnsCases := map[string]struct {
+		domain      string
+		target      string
+		expected    string
+		notExpected string
+	}{
+		"Subdomain":    {domain: "app.example.com", target: "web.user.apps.example.net", expected: "CNAME  app.example.com.  web.user.apps.example.net."},
+		"SecondLevel":  {domain: "app.example.co.uk", target: "web.user.apps.example.net", expected: "CNAME  app.example.co.uk.  web.user.apps.example.net."},
+		"AliasNote":    {domain: "example.co.uk", target: "web.user.apps.example.net", expected: "If example.co.uk is an apex domain"},
+		"NoURLYet":     {domain: "app.example.com", expected: "App has no URL yet", notExpected: "CNAME  app.example.com.  ."},
+		"NoEmptyValue": {domain: "example.com", notExpected: "TYPE"},
+	}
+	for testName, test := range dnsCases {
+		records := dnsRecords(test.domain, test.target)
+		if test.expected != "" && !strings.Contains(records, test.expected) {
+			t.Errorf("test case %s: expected %q in %q", testName, test.expected, records)
+		}
+		if test.notExpected != "" && strings.Contains(records, test.notExpected) {
+			t.Errorf("test case %s: expected no %q in %q", testName, test.notExpected, records)
+		}
+	}
+}
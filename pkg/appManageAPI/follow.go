@@ -0,0 +1,177 @@
+package appManageAPI
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/platform9/appctl/pkg/appAPIs"
+	"github.com/platform9/appctl/pkg/constants"
+	"github.com/platform9/appctl/pkg/registry"
+)
+
+// An app as deploy creates it, to deploy it again when its tag moves.
+type Deployment struct {
+	Name string
+	// Image as given, with the tag to follow.
+	Image       string
+	Username    string
+	Password    string
+	Env         []string
+	EnvFilePath string
+	Port        string
+	Options     appAPIs.ContainerOptions
+	// To deploy the tag as given, instead of the digest it points to.
+	KeepTag bool
+	// Key the image must be signed with, if any.
+	SignatureKey *ecdsa.PublicKey
+}
+
+// Hooks used to follow a tag, replaced in tests.
+var (
+	resolveTag = resolveTagDigest
+	redeploy   = redeployApp
+)
+
+// To poll the registry for the digest the tag of the app points to, every
+// interval, and deploy the app again each time it changes, until ctx is
+// done. digest is the one deployed, empty if it isn't known yet. Registry
+// and deploy errors are retried, backing off up to FOLLOWTAGMAXBACKOFF,
+// except rejected credentials, which no retry fixes.
+//
+// There is no API to update an app, so it is deleted and created again, and
+// is unavailable while its new revision starts. A rollout in progress isn't
+// interrupted, to not leave the app deleted.
+func FollowTag(ctx context.Context, deployment Deployment, digest string, interval time.Duration) error {
+	ref, err := registry.ParseReference(deployment.Image)
+	if err != nil {
+		return fmt.Errorf("Invalid image: %v.\n", err)
+	}
+	if ref.Digest != "" {
+		return fmt.Errorf("Image %v is given by digest, there is no tag to follow.\n", deployment.Image)
+	}
+
+	fmt.Printf("\nWarning: apps can't be updated in place, each rollout deletes app %v and creates it again. "+
+		"The app is down until its new revision starts, and stays deleted if creating it fails, until a later rollout succeeds.\n", deployment.Name)
+	fmt.Printf("\nFollowing %v every %v, press Ctrl+C to stop.\n", deployment.Image, interval)
+	delay := interval
+	for waitFor(ctx, delay) {
+		current, err := resolveTag(ctx, ref, deployment.Username, deployment.Password)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			if errors.Is(err, registry.ErrUnauthorized) {
+				return fmt.Errorf("Stopped following %v, the registry rejected the credentials: %v.\n", deployment.Image, err)
+			}
+			delay = backoff(delay, interval)
+			followLog("Couldn't check %v, retrying in %v: %v", deployment.Image, delay, err)
+			continue
+		}
+
+		switch {
+		case digest == "":
+			followLog("%v points to %v.", deployment.Image, current)
+		case current != digest:
+			followLog("%v moved from %v to %v, deploying app %v again.", deployment.Image, shortDigest(digest), shortDigest(current), deployment.Name)
+			if err := redeploy(deployment, current); err != nil {
+				delay = backoff(delay, interval)
+				followLog("Rollout of %v failed, retrying in %v: %v", shortDigest(current), delay, err)
+				continue
+			}
+			followLog("Rolled out %v to app %v.", shortDigest(current), deployment.Name)
+		}
+		digest = current
+		delay = interval
+	}
+	fmt.Printf("Stopped following %v.\n", deployment.Image)
+	return nil
+}
+
+// To wait for delay, returns false if ctx is done first.
+func waitFor(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// The delay after another failure, twice the last one up to FOLLOWTAGMAXBACKOFF.
+func backoff(delay time.Duration, interval time.Duration) time.Duration {
+	delay *= 2
+	if max := constants.FOLLOWTAGMAXBACKOFF * time.Second; delay > max {
+		delay = max
+	}
+	if delay < interval {
+		delay = interval
+	}
+	return delay
+}
+
+// To print a line of the follow log, with the time. Secrets are masked, the
+// errors of rollouts can include the request.
+func followLog(format string, args ...interface{}) {
+	fmt.Printf("%v %v\n", now().Format("15:04:05"), MaskSecrets(strings.TrimSpace(fmt.Sprintf(format, args...))))
+}
+
+// To get the digest a tag points to now.
+func resolveTagDigest(ctx context.Context, ref registry.Reference, username string, password string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, constants.IMAGECHECKTIMEOUT*time.Second)
+	defer cancel()
+	// A new client each time, so expired registry tokens aren't reused.
+	client := registry.Client{Username: username, Password: password}
+	manifest, err := client.Manifest(ctx, ref, ref.Tag)
+	if err != nil {
+		return "", err
+	}
+	if manifest.Digest == "" {
+		return "", fmt.Errorf("the registry didn't send the digest of %v", ref)
+	}
+	return manifest.Digest, nil
+}
+
+// To deploy an app again from the digest its tag points to, deleting it and
+// creating it again once it is gone.
+func redeployApp(deployment Deployment, digest string) error {
+	// An unsigned image doesn't replace the running one.
+	if deployment.SignatureKey != nil {
+		if _, err := VerifyImageSignature(deployment.Image, digest, deployment.SignatureKey, deployment.Username, deployment.Password); err != nil {
+			return err
+		}
+	}
+	// The digest verified is deployed, even with --keep-tag.
+	image := deployment.Image
+	if !deployment.KeepTag || deployment.SignatureKey != nil {
+		image = PinImage(&deployment.Options, deployment.Image, digest)
+	}
+
+	token, err := loadToken("deploy app")
+	if err != nil {
+		return err
+	}
+	// The app is already deleted if creating it failed on a previous rollout.
+	if _, err := appAPIs.GetAppByName(deployment.Name, token); err == nil {
+		if err := deleteApp(deployment.Name, token); err != nil {
+			return fmt.Errorf("failed to delete app: %v", err)
+		}
+		gone := false
+		for attempt := 0; attempt < constants.APPDELETEPOLLATTEMPTS && !gone; attempt++ {
+			sleep(constants.APPDEPLOYINTERVAL * time.Second)
+			_, err := appAPIs.GetAppByName(deployment.Name, token)
+			gone = err != nil
+		}
+		if !gone {
+			return fmt.Errorf("app %v is still being deleted", deployment.Name)
+		}
+	}
+
+	return CreateApp(deployment.Name, image, deployment.Username, deployment.Password,
+		deployment.Env, deployment.EnvFilePath, deployment.Port, deployment.Options)
+}
@@ -0,0 +1,152 @@
+package appManageAPI
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/platform9/appctl/pkg/constants"
+	"github.com/platform9/appctl/pkg/registry"
+)
+
+func TestFollowTag(t *testing.T) {
+	defer func(resolve func(context.Context, registry.Reference, string, string) (string, error), deploy func(Deployment, string) error) {
+		resolveTag, redeploy = resolve, deploy
+	}(resolveTag, redeploy)
+
+	followCases := map[string]struct {
+		digest string
+		// Digests the tag resolves to on each poll, empty for a registry error.
+		resolved []string
+		// To fail each rollout.
+		deployErr       error
+		expectedDeploys []string
+	}{
+		"Unchanged":      {digest: deployedDigest, resolved: []string{deployedDigest, deployedDigest}},
+		"Moved":          {digest: deployedDigest, resolved: []string{deployedDigest, pushedDigest, pushedDigest}, expectedDeploys: []string{pushedDigest}},
+		"MovedBack":      {digest: deployedDigest, resolved: []string{pushedDigest, deployedDigest}, expectedDeploys: []string{pushedDigest, deployedDigest}},
+		"RegistryErrors": {digest: deployedDigest, resolved: []string{"", "", pushedDigest}, expectedDeploys: []string{pushedDigest}},
+		// The digest first resolved is taken as the one deployed.
+		"UnknownDigest": {resolved: []string{deployedDigest, deployedDigest}},
+		// Failed rollouts are retried until one succeeds.
+		"RolloutFails": {digest: deployedDigest, resolved: []string{pushedDigest, pushedDigest}, deployErr: errors.New("quota exceeded"), expectedDeploys: []string{pushedDigest, pushedDigest}},
+	}
+	for testName, test := range followCases {
+		ctx, cancel := context.WithCancel(context.Background())
+		polls := 0
+		resolveTag = func(ctx context.Context, ref registry.Reference, username string, password string) (string, error) {
+			if ref.Tag != "dev" || username != "jane" {
+				t.Errorf("test case %s: resolved %v as %q", testName, ref, username)
+			}
+			digest := test.resolved[polls]
+			polls++
+			if polls == len(test.resolved) {
+				// Interrupted after the last poll.
+				cancel()
+			}
+			if digest == "" {
+				return "", errors.New("registry unavailable")
+			}
+			return digest, nil
+		}
+		var deploys []string
+		redeploy = func(deployment Deployment, digest string) error {
+			deploys = append(deploys, digest)
+			return test.deployErr
+		}
+
+		deployment := Deployment{Name: "app", Image: "org/app:dev", Username: "jane"}
+		if err := FollowTag(ctx, deployment, test.digest, time.Millisecond); err != nil {
+			t.Errorf("test case %s: failed with error: %v", testName, err)
+		}
+		if polls != len(test.resolved) {
+			t.Errorf("test case %s: expected %d polls, got %d", testName, len(test.resolved), polls)
+		}
+		if strings.Join(deploys, ",") != strings.Join(test.expectedDeploys, ",") {
+			t.Errorf("test case %s: expected rollouts of %v, got %v", testName, test.expectedDeploys, deploys)
+		}
+		cancel()
+	}
+
+	// Rejected credentials aren't retried.
+	polls := 0
+	resolveTag = func(ctx context.Context, ref registry.Reference, username string, password string) (string, error) {
+		polls++
+		return "", fmt.Errorf("%w: ghcr.io needs a username and password", registry.ErrUnauthorized)
+	}
+	err := FollowTag(context.Background(), Deployment{Name: "app", Image: "ghcr.io/org/app:dev"}, deployedDigest, time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "rejected the credentials") || polls != 1 {
+		t.Errorf("test case Unauthorized: expected to stop after 1 poll, got %d polls and error: %v", polls, err)
+	}
+
+	if err := FollowTag(context.Background(), Deployment{Image: "org/app@" + deployedDigest}, "", time.Millisecond); err == nil {
+		t.Errorf("test case ByDigest: expected an error following a digest")
+	}
+}
+
+// A rollout that fails to create the app after deleting it leaves it
+// deleted, the next one creates it.
+func TestFollowTagRecreate(t *testing.T) {
+	useDummyLogin(t)
+	savedSleep, savedResolve := sleep, resolveTag
+	t.Cleanup(func() { sleep, resolveTag = savedSleep, savedResolve })
+	sleep = func(time.Duration) {}
+
+	exists, creates := true, 0
+	appURL := fmt.Sprintf("%s/%s", constants.APPURL, "app")
+	httpmock.RegisterResponder(http.MethodGet, appURL, func(req *http.Request) (*http.Response, error) {
+		if !exists {
+			return httpmock.NewStringResponse(400, ""), nil
+		}
+		return httpmock.NewJsonResponse(200, dummyApp("app", nil, time.Hour))
+	})
+	httpmock.RegisterResponder(http.MethodDelete, appURL, func(req *http.Request) (*http.Response, error) {
+		exists = false
+		return httpmock.NewStringResponse(200, ""), nil
+	})
+	httpmock.RegisterResponder(http.MethodPost, constants.APPURL, func(req *http.Request) (*http.Response, error) {
+		creates++
+		if creates == 1 {
+			return httpmock.NewStringResponse(500, ""), nil
+		}
+		exists = true
+		return httpmock.NewStringResponse(200, ""), nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	polls := 0
+	resolveTag = func(ctx context.Context, ref registry.Reference, username string, password string) (string, error) {
+		polls++
+		if polls == 2 {
+			cancel()
+		}
+		return pushedDigest, nil
+	}
+
+	if err := FollowTag(ctx, Deployment{Name: "app", Image: "org/app:dev"}, deployedDigest, time.Millisecond); err != nil {
+		t.Fatalf("failed with error: %v", err)
+	}
+	calls := httpmock.GetCallCountInfo()
+	if deletes := calls["DELETE "+appURL]; deletes != 1 {
+		t.Errorf("expected the app to be deleted once, got %d deletes", deletes)
+	}
+	if creates != 2 || !exists {
+		t.Errorf("expected the app to be created again on the next tick, got %d creates, exists: %v", creates, exists)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	interval := 10 * time.Second
+	delay := interval
+	for _, expected := range []time.Duration{20 * time.Second, 40 * time.Second, 80 * time.Second, 160 * time.Second, 300 * time.Second, 300 * time.Second} {
+		if delay = backoff(delay, interval); delay != expected {
+			t.Errorf("expected a delay of %v, got %v", expected, delay)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package appManageAPI
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/platform9/appctl/pkg/constants"
+	"github.com/platform9/appctl/pkg/registry"
+	"github.com/ryanuber/columnize"
+)
+
+// To check the image exists in its registry before deploying it, returning
+// its details. This fails in seconds where the server takes tens of seconds
+// to report the image can't be fetched. Images without a variant for the
+// platform of the apps are only warned about.
+//
+// Only a missing image or rejected credentials fail, the image is deployed
+// anyway without details if the registry can't be reached from here. With
+// storedCredentials, the registry credentials are on the server, so the
+// registry asking for credentials isn't an error.
+func PreflightImage(image string, username string, password string, storedCredentials bool) (*registry.ImageInfo, error) {
+	ref, err := registry.ParseReference(image)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid image: %v.\n", err)
+	}
+
+	info, err := inspect(ref, username, password)
+	switch {
+	case err == nil:
+	case errors.Is(err, registry.ErrUnauthorized) && storedCredentials:
+		return nil, nil
+	case errors.Is(err, registry.ErrUnauthorized), errors.Is(err, registry.ErrNotFound):
+		return nil, fmt.Errorf("Image %v can't be deployed: %v.\nUse --skip-image-check to deploy it anyway.\n", image, err)
+	default:
+		fmt.Printf("Couldn't check image %v, deploying it anyway: %v\n", image, err)
+		return nil, nil
+	}
+
+	if !info.Supports(registry.DefaultPlatform) {
+		fmt.Printf("Warning: image %v has no %v variant, only %v. The app will likely fail to start.\n",
+			image, registry.DefaultPlatform, platformList(info))
+	}
+	return info, nil
+}
+
+// To print the ports, platforms, size, entrypoint and digest of an image.
+func InspectImage(image string, username string, password string, output string) error {
+	ref, err := registry.ParseReference(image)
+	if err != nil {
+		return fmt.Errorf("Invalid image: %v.\n", err)
+	}
+	info, err := inspect(ref, username, password)
+	if err != nil {
+		return fmt.Errorf("Failed to inspect image %v with error: %v.\n", image, err)
+	}
+
+	if output == DescribeJSON {
+		data, _ := json.MarshalIndent(info, "", "    ")
+		fmt.Println(string(data))
+		return nil
+	}
+	rows := []string{
+		"Image: | " + info.Reference,
+		"Digest: | " + info.Digest,
+		"Ports: | " + valueOrNone(strings.Join(info.Ports, ", ")),
+		"Entrypoint: | " + valueOrNone(strings.Join(info.Entrypoint, " ")),
+		"Cmd: | " + valueOrNone(strings.Join(info.Cmd, " ")),
+	}
+	if len(info.Platforms) > 1 {
+		rows = append(rows, fmt.Sprintf("Config of: | %v", info.ConfigPlatform))
+	}
+	fmt.Println(columnize.SimpleFormat(rows))
+	fmt.Println()
+	platforms := []string{"PLATFORM | SIZE | DIGEST"}
+	for _, platform := range info.Platforms {
+		platforms = append(platforms, fmt.Sprintf("%v | %v | %v", platform.Platform, humanSize(platform.Size), platform.Digest))
+	}
+	fmt.Println(columnize.SimpleFormat(platforms))
+	if !info.Supports(registry.DefaultPlatform) {
+		fmt.Printf("\nWarning: apps run on %v, which the image has no variant for.\n", registry.DefaultPlatform)
+	}
+	return nil
+}
+
+func inspect(ref registry.Reference, username string, password string) (*registry.ImageInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), constants.IMAGECHECKTIMEOUT*time.Second)
+	defer cancel()
+	client := registry.Client{Username: username, Password: password}
+	return client.Inspect(ctx, ref)
+}
+
+func platformList(info *registry.ImageInfo) string {
+	var platforms []string
+	for _, platform := range info.Platforms {
+		platforms = append(platforms, platform.Platform.String())
+	}
+	return valueOrNone(strings.Join(platforms, ", "))
+}
+
+// To format a size in bytes like 12.3 MB.
+func humanSize(size int64) string {
+	const unit = 1000
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, exp := float64(size)/unit, 0
+	for value >= unit && exp < 3 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", value, "kMGT"[exp])
+}
@@ -0,0 +1,63 @@
+package appManageAPI
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPreflightImage(t *testing.T) {
+	// A registry that needs credentials, and has no tags.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, _, _ := r.BasicAuth(); username == "" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errors":[{"code":"MANIFEST_UNKNOWN"}]}`))
+	}))
+	defer server.Close()
+	image := strings.TrimPrefix(server.URL, "http://") + "/org/app:v1"
+
+	imageCases := map[string]struct {
+		image             string
+		username          string
+		storedCredentials bool
+		expectedErr       string
+	}{
+		"InvalidReference":  {image: "Org/App", expectedErr: "Invalid image"},
+		"MissingTag":        {image: image, username: "jane", expectedErr: "check the tag"},
+		"NoCredentials":     {image: image, expectedErr: "needs a username and password"},
+		"StoredCredentials": {image: image, storedCredentials: true},
+		// Registries that can't be reached from here don't stop the deploy.
+		"Unreachable": {image: "127.0.0.1:1/org/app:v1"},
+	}
+	for testName, test := range imageCases {
+		_, err := PreflightImage(test.image, test.username, "secret", test.storedCredentials)
+		if test.expectedErr == "" {
+			if err != nil {
+				t.Errorf("test case %s: failed with error: %v", testName, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+			t.Errorf("test case %s: expected error %q, got %v", testName, test.expectedErr, err)
+		}
+	}
+}
+
+func TestHumanSize(t *testing.T) {
+	sizeCases := map[int64]string{
+		512:        "512 B",
+		1500:       "1.5 kB",
+		12_345_678: "12.3 MB",
+		2e9:        "2.0 GB",
+	}
+	for size, expected := range sizeCases {
+		if human := humanSize(size); human != expected {
+			t.Errorf("test case %d: expected %q, got %q", size, expected, human)
+		}
+	}
+}
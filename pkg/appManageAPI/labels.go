@@ -0,0 +1,78 @@
+package appManageAPI
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/platform9/appctl/pkg/appAPIs"
+)
+
+// Kubernetes label names and values, and the optional DNS prefix of names.
+var (
+	labelNameRegex   = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]{0,61}[A-Za-z0-9])?$`)
+	labelValueRegex  = regexp.MustCompile(`^([A-Za-z0-9]([-A-Za-z0-9_.]{0,61}[A-Za-z0-9])?)?$`)
+	labelPrefixRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+)
+
+// To parse labels given as key=value pairs, checking they are valid
+// Kubernetes labels.
+func ParseLabels(labels []string) (map[string]string, error) {
+	if len(labels) == 0 {
+		return nil, nil
+	}
+	parsed := map[string]string{}
+	for _, label := range labels {
+		i := strings.Index(label, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("Invalid label %q, set it as key=value.", label)
+		}
+		key, value := label[:i], label[i+1:]
+		name := key
+		if i := strings.LastIndex(key, "/"); i >= 0 {
+			prefix := key[:i]
+			name = key[i+1:]
+			if len(prefix) > 253 || !labelPrefixRegex.MatchString(prefix) {
+				return nil, fmt.Errorf("Invalid label %q, its prefix should be a DNS subdomain like example.com.", label)
+			}
+		}
+		if !labelNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("Invalid label %q, its name should be up to 63 alphanumeric characters, '-', '_' or '.'.", label)
+		}
+		if !labelValueRegex.MatchString(value) {
+			return nil, fmt.Errorf("Invalid label %q, its value should be up to 63 alphanumeric characters, '-', '_' or '.'.", label)
+		}
+		if _, ok := parsed[key]; ok {
+			return nil, fmt.Errorf("Duplicate label %v.", key)
+		}
+		parsed[key] = value
+	}
+	return parsed, nil
+}
+
+// To set the labels of the app.
+func SetLabels(options *appAPIs.ContainerOptions, labels []string) error {
+	parsed, err := ParseLabels(labels)
+	if err != nil {
+		return err
+	}
+	options.Labels = parsed
+	return nil
+}
+
+// Labels of the app as key=value pairs, sorted.
+func describeLabels(get_app map[string]interface{}) string {
+	metadata, _ := get_app["metadata"].(map[string]interface{})
+	labels, _ := metadata["labels"].(map[string]interface{})
+	var pairs []string
+	for key, value := range labels {
+		// Labels set by Knative and the server.
+		if strings.Contains(key, "knative.dev/") {
+			continue
+		}
+		pairs = append(pairs, fmt.Sprintf("%v=%v", key, value))
+	}
+	sort.Strings(pairs)
+	return valueOrNone(strings.Join(pairs, ", "))
+}
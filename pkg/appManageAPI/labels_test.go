@@ -0,0 +1,46 @@
+package appManageAPI
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLabels(t *testing.T) {
+	labelCases := map[string]struct {
+		labels      []string
+		expected    map[string]string
+		expectedErr string
+	}{
+		"None":        {},
+		"Labels":      {labels: []string{"team=payments", "cost-center=42"}, expected: map[string]string{"team": "payments", "cost-center": "42"}},
+		"Prefixed":    {labels: []string{"acme.com/owner=jane"}, expected: map[string]string{"acme.com/owner": "jane"}},
+		"EmptyValue":  {labels: []string{"canary="}, expected: map[string]string{"canary": ""}},
+		"NoValue":     {labels: []string{"team"}, expectedErr: "set it as key=value"},
+		"InvalidName": {labels: []string{"-team=payments"}, expectedErr: "its name should be"},
+		"LongValue":   {labels: []string{"team=" + strings.Repeat("a", 64)}, expectedErr: "its value should be"},
+		"SpaceValue":  {labels: []string{"team=the payments team"}, expectedErr: "its value should be"},
+		"BadPrefix":   {labels: []string{"Acme_Com/owner=jane"}, expectedErr: "DNS subdomain"},
+		"Duplicate":   {labels: []string{"team=a", "team=b"}, expectedErr: "Duplicate label team"},
+	}
+	for testName, test := range labelCases {
+		labels, err := ParseLabels(test.labels)
+		if test.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+				t.Errorf("test case %s: expected error %q, got %v", testName, test.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test case %s: failed with error: %v", testName, err)
+			continue
+		}
+		if len(labels) != len(test.expected) {
+			t.Errorf("test case %s: expected %v, got %v", testName, test.expected, labels)
+		}
+		for key, value := range test.expected {
+			if labels[key] != value {
+				t.Errorf("test case %s: expected %v=%q, got %q", testName, key, value, labels[key])
+			}
+		}
+	}
+}
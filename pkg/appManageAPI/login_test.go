@@ -0,0 +1,205 @@
+package appManageAPI
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/platform9/appctl/pkg/appAPIs"
+	"github.com/platform9/appctl/pkg/constants"
+)
+
+const (
+	dummyDomain     = "appctl.test"
+	dummyDeviceCode = "dummyDeviceCode"
+	dummyIDToken    = "dummyIDToken"
+)
+
+// Fake authorization server, replies to token polls from a list of responses.
+// Each response is either a token error code, or "" for a successful login.
+type fakeAuthServer struct {
+	responses []string
+	polls     int
+}
+
+func (f *fakeAuthServer) register(t *testing.T, deviceInfo map[string]interface{}) {
+	httpmock.RegisterResponder(http.MethodPost, constants.DEVICECODEURL, func(req *http.Request) (*http.Response, error) {
+		return httpmock.NewJsonResponse(200, deviceInfo)
+	})
+	httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("https://%s/oauth/token", constants.DOMAIN), func(req *http.Request) (*http.Response, error) {
+		req.ParseForm()
+		if req.PostForm.Get("device_code") != dummyDeviceCode {
+			t.Errorf("unexpected device code in token request: %v", req.PostForm)
+		}
+		if f.polls >= len(f.responses) {
+			t.Fatalf("token endpoint polled %d times, only %d responses expected", f.polls+1, len(f.responses))
+		}
+		response := f.responses[f.polls]
+		f.polls++
+		switch response {
+		case "":
+			return httpmock.NewJsonResponse(200, map[string]interface{}{"id_token": dummyIDToken, "expires_in": 3600})
+		case "network_error":
+			return nil, fmt.Errorf("connection reset")
+		default:
+			return httpmock.NewJsonResponse(400, map[string]interface{}{"error": response})
+		}
+	})
+}
+
+// Points the login flow at the fake authorization server, with a fake clock.
+// Returns the intervals the flow slept for.
+func useFakeAuthServer(t *testing.T) *[]time.Duration {
+	savedDomain, savedDeviceURL, savedGrantType := constants.DOMAIN, constants.DEVICECODEURL, constants.GrantType
+	savedSleep, savedNow, savedOpenBrowser := sleep, now, openBrowser
+	constants.DOMAIN = dummyDomain
+	constants.DEVICECODEURL = fmt.Sprintf("https://%s/oauth/device/code", dummyDomain)
+	constants.GrantType = "grant_type=" + url.QueryEscape("urn:ietf:params:oauth:grant-type:device_code")
+
+	clock := time.Now()
+	var slept []time.Duration
+	now = func() time.Time { return clock }
+	sleep = func(d time.Duration) {
+		slept = append(slept, d)
+		clock = clock.Add(d)
+	}
+	openBrowser = func(url string) error {
+		t.Errorf("browser should not be opened, got URL: %v", url)
+		return nil
+	}
+
+	httpmock.Activate()
+	t.Cleanup(func() {
+		httpmock.DeactivateAndReset()
+		constants.DOMAIN, constants.DEVICECODEURL, constants.GrantType = savedDomain, savedDeviceURL, savedGrantType
+		sleep, now, openBrowser = savedSleep, savedNow, savedOpenBrowser
+	})
+	return &slept
+}
+
+func TestPollToken(t *testing.T) {
+	pollTokenCases := map[string]struct {
+		interval          int
+		expiresIn         int
+		responses         []string
+		expectedIDToken   string
+		expectedError     string
+		expectedFailure   bool
+		expectedIntervals []time.Duration
+	}{
+		"Success": {
+			interval:          2,
+			expiresIn:         60,
+			responses:         []string{"authorization_pending", "authorization_pending", ""},
+			expectedIDToken:   dummyIDToken,
+			expectedIntervals: []time.Duration{2 * time.Second, 2 * time.Second, 2 * time.Second},
+		},
+		"DefaultInterval": {
+			responses:         []string{""},
+			expectedIDToken:   dummyIDToken,
+			expectedIntervals: []time.Duration{constants.TOKENPOLLINTERVAL * time.Second},
+		},
+		"SlowDown": {
+			interval:          5,
+			expiresIn:         60,
+			responses:         []string{"slow_down", "authorization_pending", "slow_down", ""},
+			expectedIDToken:   dummyIDToken,
+			expectedIntervals: []time.Duration{5 * time.Second, 10 * time.Second, 10 * time.Second, 15 * time.Second},
+		},
+		"AccessDenied": {
+			interval:      5,
+			expiresIn:     60,
+			responses:     []string{"authorization_pending", "access_denied"},
+			expectedError: "access_denied",
+		},
+		"ExpiredToken": {
+			interval:      5,
+			expiresIn:     60,
+			responses:     []string{"expired_token"},
+			expectedError: "expired_token",
+		},
+		"DeadlineReached": {
+			interval:          5,
+			expiresIn:         12,
+			responses:         []string{"authorization_pending", "authorization_pending"},
+			expectedError:     "expired_token",
+			expectedIntervals: []time.Duration{5 * time.Second, 5 * time.Second, 5 * time.Second},
+		},
+		"NetworkErrorRetried": {
+			interval:        5,
+			expiresIn:       60,
+			responses:       []string{"network_error", ""},
+			expectedIDToken: dummyIDToken,
+		},
+		"NetworkErrorUntilDeadline": {
+			interval:        5,
+			expiresIn:       7,
+			responses:       []string{"network_error"},
+			expectedFailure: true,
+		},
+	}
+
+	for testName, test := range pollTokenCases {
+		t.Run(testName, func(t *testing.T) {
+			slept := useFakeAuthServer(t)
+			server := &fakeAuthServer{responses: test.responses}
+			server.register(t, nil)
+
+			token, err := pollToken(&appAPIs.DeviceInfo{
+				DeviceCode: dummyDeviceCode,
+				Interval:   test.interval,
+				ExpiresIn:  test.expiresIn,
+			})
+			if test.expectedFailure {
+				if err == nil {
+					t.Errorf("expected error, got token: %+v", token)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed with error: %v", err)
+			}
+			if token.IdToken != test.expectedIDToken || token.Error != test.expectedError {
+				t.Errorf("expected id token %q and error %q, got %+v", test.expectedIDToken, test.expectedError, token)
+			}
+			if server.polls != len(test.responses) {
+				t.Errorf("expected %d polls, got %d", len(test.responses), server.polls)
+			}
+			if test.expectedIntervals != nil && fmt.Sprint(*slept) != fmt.Sprint(test.expectedIntervals) {
+				t.Errorf("expected poll intervals %v, got %v", test.expectedIntervals, *slept)
+			}
+		})
+	}
+}
+
+func TestLoginAppNoBrowser(t *testing.T) {
+	useFakeAuthServer(t)
+	useTempConfig(t)
+
+	httpmock.RegisterResponder(http.MethodGet, "https://google.com", httpmock.NewStringResponder(200, ""))
+	httpmock.RegisterResponder(http.MethodPost, constants.APPURL+"/login", httpmock.NewStringResponder(200, ""))
+	server := &fakeAuthServer{responses: []string{"authorization_pending", "slow_down", ""}}
+	server.register(t, map[string]interface{}{
+		"device_code":               dummyDeviceCode,
+		"user_code":                 "ABCD-EFGH",
+		"verification_uri":          "https://appctl.test/activate",
+		"verification_uri_complete": "https://appctl.test/activate?user_code=ABCD-EFGH",
+		"expires_in":                900,
+		"interval":                  5,
+	})
+
+	if err := LoginApp(true); err != nil {
+		t.Fatalf("failed with error: %v", err)
+	}
+
+	config, err := loadConfig(constants.CONFIGFILEPATH)
+	if err != nil {
+		t.Fatalf("failed with error: %v", err)
+	}
+	if config.IDToken != dummyIDToken {
+		t.Errorf("expected id token %q in config, got %q", dummyIDToken, config.IDToken)
+	}
+}
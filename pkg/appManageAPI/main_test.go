@@ -0,0 +1,14 @@
+package appManageAPI
+
+import (
+	"os"
+	"testing"
+
+	"github.com/platform9/appctl/pkg/segment"
+)
+
+func TestMain(m *testing.M) {
+	// Don't send usage events from tests.
+	tracker = segment.NopTracker{}
+	os.Exit(m.Run())
+}
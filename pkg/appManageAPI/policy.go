@@ -0,0 +1,102 @@
+package appManageAPI
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/platform9/appctl/pkg/policy"
+	"github.com/ryanuber/columnize"
+)
+
+// To describe an app to deploy for the policy, from the deploy flags.
+func PolicyRequest(name string, image string, env []string, port string, labels map[string]string) (policy.Request, error) {
+	request := policy.Request{Name: name, Image: image, Labels: labels, Env: map[string]string{}}
+	for _, pair := range env {
+		split := strings.SplitN(pair, "=", 2)
+		request.Env[split[0]] = split[len(split)-1]
+	}
+	if port != "" {
+		number, err := strconv.Atoi(port)
+		if err != nil {
+			return request, fmt.Errorf("Invalid port %v.\n", port)
+		}
+		request.Port = number
+	}
+	return request, nil
+}
+
+// To check an app against the deployment policy before deploying it. file
+// is the policy given with --policy, the default policy file is used if it
+// is empty, and apps aren't checked without one. Returns an error listing
+// the rules the app breaks.
+func CheckPolicy(file string, request policy.Request) error {
+	rules, err := policy.Load(file)
+	if err != nil {
+		return fmt.Errorf("Failed to load the deployment policy with error: %v.\n", err)
+	}
+	if rules == nil {
+		return nil
+	}
+	violations := rules.Evaluate(request)
+	if len(violations) == 0 {
+		return nil
+	}
+	printViolations(violations)
+	return fmt.Errorf("\nApp %v breaks %v of the deployment policy, not deploying it.\n", request.Name, ruleCount(violations))
+}
+
+// To check app manifests against a policy offline, printing the violations
+// of each app. Returns an error if any app breaks a rule.
+func PolicyTest(file string, manifests []string) error {
+	if file == "" {
+		file = policy.Path()
+	}
+	rules, err := policy.Load(file)
+	if err != nil {
+		return fmt.Errorf("Failed to load the deployment policy with error: %v.\n", err)
+	}
+
+	failed := 0
+	for _, manifest := range manifests {
+		requests, err := policy.LoadManifests(manifest)
+		if err != nil {
+			return fmt.Errorf("Failed to load manifests with error: %v.\n", err)
+		}
+		for _, request := range requests {
+			violations := rules.Evaluate(request)
+			if len(violations) == 0 {
+				fmt.Printf("PASS  %v (%v)\n", valueOrNone(request.Name), manifest)
+				continue
+			}
+			failed++
+			fmt.Printf("FAIL  %v (%v)\n", valueOrNone(request.Name), manifest)
+			printViolations(violations)
+			fmt.Println()
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d apps break the deployment policy %v.\n", failed, file)
+	}
+	return nil
+}
+
+func printViolations(violations []policy.Violation) {
+	rows := []string{"RULE | VIOLATION"}
+	for _, violation := range violations {
+		rows = append(rows, fmt.Sprintf("%v | %v", violation.RuleID, violation.Message))
+	}
+	fmt.Println(columnize.SimpleFormat(rows))
+}
+
+// Number of rules broken, as "1 rule" or "2 rules".
+func ruleCount(violations []policy.Violation) string {
+	rules := map[string]bool{}
+	for _, violation := range violations {
+		rules[violation.RuleID] = true
+	}
+	if len(rules) == 1 {
+		return "1 rule"
+	}
+	return fmt.Sprintf("%d rules", len(rules))
+}
@@ -0,0 +1,76 @@
+package appManageAPI
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const dummyPolicy = `
+rules:
+  - id: no-latest
+    disallowLatest: true
+  - id: owner-labels
+    requiredLabels: [team]
+  - id: no-cloud-credentials
+    forbiddenEnv: ["AWS_*"]
+`
+
+func TestCheckPolicy(t *testing.T) {
+	dir := t.TempDir()
+	policyFile := filepath.Join(dir, "policy.yaml")
+	ioutil.WriteFile(policyFile, []byte(dummyPolicy), 0600)
+	// No default policy.
+	t.Setenv("APPCTL_POLICY", filepath.Join(dir, "missing.yaml"))
+
+	policyCases := map[string]struct {
+		policyFile  string
+		image       string
+		env         []string
+		labels      map[string]string
+		expectedErr string
+	}{
+		"Compliant":     {policyFile: policyFile, image: "org/app:v1", env: []string{"LEVEL=debug"}, labels: map[string]string{"team": "payments"}},
+		"NoPolicy":      {image: "org/app"},
+		"MissingPolicy": {policyFile: filepath.Join(dir, "missing.yaml"), image: "org/app:v1", expectedErr: "Failed to load the deployment policy"},
+		"Latest":        {policyFile: policyFile, image: "org/app", labels: map[string]string{"team": "payments"}, expectedErr: "breaks 1 rule of"},
+		"EnvAndTag":     {policyFile: policyFile, image: "org/app:latest", env: []string{"AWSKEY=secret", "AWS_REGION=east"}, labels: map[string]string{"team": "payments"}, expectedErr: "breaks 2 rules of"},
+	}
+	for testName, test := range policyCases {
+		request, err := PolicyRequest("app", test.image, test.env, "", test.labels)
+		if err == nil {
+			err = CheckPolicy(test.policyFile, request)
+		}
+		if test.expectedErr == "" {
+			if err != nil {
+				t.Errorf("test case %s: failed with error: %v", testName, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+			t.Errorf("test case %s: expected error %q, got %v", testName, test.expectedErr, err)
+		}
+	}
+}
+
+func TestPolicyTest(t *testing.T) {
+	dir := t.TempDir()
+	policyFile := filepath.Join(dir, "policy.yaml")
+	ioutil.WriteFile(policyFile, []byte(dummyPolicy), 0600)
+	compliant := filepath.Join(dir, "compliant.yaml")
+	ioutil.WriteFile(compliant, []byte("name: api\nimage: org/api:v1\nlabels: {team: payments}\n"), 0600)
+	violating := filepath.Join(dir, "violating.yaml")
+	ioutil.WriteFile(violating, []byte("name: api\nimage: org/api:v1\nlabels: {team: payments}\n---\nname: worker\nimage: org/worker\n"), 0600)
+
+	if err := PolicyTest(policyFile, []string{compliant}); err != nil {
+		t.Errorf("test case Compliant: failed with error: %v", err)
+	}
+	if err := PolicyTest(policyFile, []string{compliant, violating}); err == nil || !strings.Contains(err.Error(), "1 apps break") {
+		t.Errorf("test case Violating: expected 1 app to break the policy, got %v", err)
+	}
+	t.Setenv("APPCTL_POLICY", filepath.Join(dir, "missing.yaml"))
+	if err := PolicyTest("", []string{compliant}); err == nil {
+		t.Errorf("test case NoPolicy: expected an error without a policy file")
+	}
+}
@@ -0,0 +1,53 @@
+package appManageAPI
+
+import (
+	"fmt"
+	"time"
+)
+
+// To poll a resource until check reports it ready or failed, fetching it at
+// most attempts times with interval in between. Errors fetching it are
+// retried. Returns the last resource fetched, if it is ready and the failure.
+func pollUntilReady(
+	fetch func() (map[string]interface{}, error), // To fetch the resource.
+	check func(resource map[string]interface{}) (bool, string), // To check if it is ready, or why it failed.
+	attempts int,
+	interval time.Duration,
+) (map[string]interface{}, bool, string) {
+	var resource map[string]interface{}
+	for attempt := 1; attempt <= attempts; attempt++ {
+		fetched, err := fetch()
+		if err == nil {
+			resource = fetched
+			ready, failure := check(resource)
+			if ready || failure != "" {
+				return resource, ready, failure
+			}
+		}
+		if attempt < attempts {
+			sleep(interval)
+		}
+	}
+	return resource, false, ""
+}
+
+// Status, reason and message of a Knative condition by type, empty if the
+// resource doesn't have it.
+func conditionStatus(resource map[string]interface{}, conditionType string) (string, string, string) {
+	status, _ := resource["status"].(map[string]interface{})
+	conditions, _ := status["conditions"].([]interface{})
+	for _, item := range conditions {
+		condition, _ := item.(map[string]interface{})
+		if condition["type"] == conditionType {
+			return stringOf(condition["status"]), stringOf(condition["reason"]), stringOf(condition["message"])
+		}
+	}
+	return "", "", ""
+}
+
+func stringOf(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,106 @@
+package appManageAPI
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/platform9/appctl/pkg/appAPIs"
+)
+
+// Probe settings of deploy, empty paths and nil durations are left to the server.
+type Probes struct {
+	// Path the app answers once it can take requests.
+	ReadinessPath string
+	// Path the app answers while it isn't stuck, it is restarted otherwise.
+	LivenessPath string
+	// Seconds between probes, and to wait for an answer.
+	PeriodSeconds  *int
+	TimeoutSeconds *int
+}
+
+// To validate the probe settings, and set them in the container options as
+// HTTP probes on the port the app listens on.
+func SetProbes(options *appAPIs.ContainerOptions, probes Probes) error {
+	if probes.ReadinessPath == "" && probes.LivenessPath == "" {
+		if probes.PeriodSeconds != nil || probes.TimeoutSeconds != nil {
+			return fmt.Errorf("--probe-period and --probe-timeout need --readiness-path or --liveness-path.")
+		}
+		return nil
+	}
+	if err := validateProbePath("readiness-path", probes.ReadinessPath); err != nil {
+		return err
+	}
+	if err := validateProbePath("liveness-path", probes.LivenessPath); err != nil {
+		return err
+	}
+	period, timeout := 0, 0
+	if probes.PeriodSeconds != nil {
+		if *probes.PeriodSeconds < 1 {
+			return fmt.Errorf("Invalid --probe-period %d, must be 1 second or more.", *probes.PeriodSeconds)
+		}
+		period = *probes.PeriodSeconds
+	}
+	if probes.TimeoutSeconds != nil {
+		if *probes.TimeoutSeconds < 1 {
+			return fmt.Errorf("Invalid --probe-timeout %d, must be 1 second or more.", *probes.TimeoutSeconds)
+		}
+		if period > 0 && *probes.TimeoutSeconds > period {
+			return fmt.Errorf("--probe-timeout %d is more than --probe-period %d.", *probes.TimeoutSeconds, period)
+		}
+		timeout = *probes.TimeoutSeconds
+	}
+	newProbe := func(path string) *appAPIs.Probe {
+		if path == "" {
+			return nil
+		}
+		return &appAPIs.Probe{
+			HTTPGet:        &appAPIs.HTTPGetAction{Path: path},
+			PeriodSeconds:  period,
+			TimeoutSeconds: timeout,
+		}
+	}
+	options.ReadinessProbe = newProbe(probes.ReadinessPath)
+	options.LivenessProbe = newProbe(probes.LivenessPath)
+	return nil
+}
+
+func validateProbePath(flag string, path string) error {
+	if path != "" && (!strings.HasPrefix(path, "/") || strings.ContainsAny(path, " \t\n")) {
+		return fmt.Errorf("Invalid --%s %q, must be a URL path like /healthz.", flag, path)
+	}
+	return nil
+}
+
+// Rows for the probes of the container.
+func probeRows(container map[string]interface{}) []string {
+	return []string{
+		"Readiness probe: | " + describeProbe(container["readinessProbe"]),
+		"Liveness probe: | " + describeProbe(container["livenessProbe"]),
+	}
+}
+
+// To describe a probe like "GET /healthz every 10s, timeout 1s".
+func describeProbe(value interface{}) string {
+	probe, _ := value.(map[string]interface{})
+	if probe == nil {
+		return notSet
+	}
+	var description string
+	if httpGet, ok := probe["httpGet"].(map[string]interface{}); ok {
+		description = "GET " + stringOf(httpGet["path"])
+		if httpGet["port"] != nil {
+			description += fmt.Sprintf(" on port %v", httpGet["port"])
+		}
+	} else if _, ok := probe["tcpSocket"]; ok {
+		description = "TCP"
+	} else {
+		description = "exec"
+	}
+	if probe["periodSeconds"] != nil {
+		description += fmt.Sprintf(" every %vs", probe["periodSeconds"])
+	}
+	if probe["timeoutSeconds"] != nil {
+		description += fmt.Sprintf(", timeout %vs", probe["timeoutSeconds"])
+	}
+	return description
+}
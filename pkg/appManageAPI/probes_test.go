@@ -0,0 +1,74 @@
+package appManageAPI
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/platform9/appctl/pkg/appAPIs"
+)
+
+func TestSetProbes(t *testing.T) {
+	probeCases := map[string]struct {
+		probes      Probes
+		expected    string
+		expectedErr string
+	}{
+		"None":      {expected: "{}"},
+		"Readiness": {probes: Probes{ReadinessPath: "/ready"}, expected: `{"readinessProbe":{"httpGet":{"path":"/ready"}}}`},
+		"Both": {
+			probes:   Probes{ReadinessPath: "/ready", LivenessPath: "/healthz", PeriodSeconds: intPtr(10), TimeoutSeconds: intPtr(2)},
+			expected: `{"readinessProbe":{"httpGet":{"path":"/ready"},"periodSeconds":10,"timeoutSeconds":2},"livenessProbe":{"httpGet":{"path":"/healthz"},"periodSeconds":10,"timeoutSeconds":2}}`,
+		},
+		"LivenessTimeout": {
+			probes:   Probes{LivenessPath: "/healthz", TimeoutSeconds: intPtr(5)},
+			expected: `{"livenessProbe":{"httpGet":{"path":"/healthz"},"timeoutSeconds":5}}`,
+		},
+		"PeriodWithoutPath": {probes: Probes{PeriodSeconds: intPtr(10)}, expectedErr: "need --readiness-path or --liveness-path"},
+		"RelativePath":      {probes: Probes{ReadinessPath: "ready"}, expectedErr: "Invalid --readiness-path"},
+		"PathWithSpace":     {probes: Probes{LivenessPath: "/health z"}, expectedErr: "Invalid --liveness-path"},
+		"ZeroPeriod":        {probes: Probes{ReadinessPath: "/ready", PeriodSeconds: intPtr(0)}, expectedErr: "Invalid --probe-period"},
+		"ZeroTimeout":       {probes: Probes{ReadinessPath: "/ready", TimeoutSeconds: intPtr(0)}, expectedErr: "Invalid --probe-timeout"},
+		"TimeoutOverPeriod": {
+			probes:      Probes{ReadinessPath: "/ready", PeriodSeconds: intPtr(2), TimeoutSeconds: intPtr(5)},
+			expectedErr: "--probe-timeout 5 is more than --probe-period 2",
+		},
+	}
+
+	for testName, test := range probeCases {
+		var options appAPIs.ContainerOptions
+		err := SetProbes(&options, test.probes)
+		if test.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+				t.Errorf("test case %s: expected error %q, got %v", testName, test.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test case %s: failed with error: %v", testName, err)
+			continue
+		}
+		data, _ := json.Marshal(options)
+		if string(data) != test.expected {
+			t.Errorf("test case %s: expected %s, got %s", testName, test.expected, data)
+		}
+	}
+}
+
+func TestProbeRows(t *testing.T) {
+	container := map[string]interface{}{
+		"readinessProbe": map[string]interface{}{
+			"httpGet":        map[string]interface{}{"path": "/ready"},
+			"periodSeconds":  float64(10),
+			"timeoutSeconds": float64(2),
+		},
+	}
+	expected := []string{
+		"Readiness probe: | GET /ready every 10s, timeout 2s",
+		"Liveness probe: | " + notSet,
+	}
+	if rows := probeRows(container); fmt.Sprint(rows) != fmt.Sprint(expected) {
+		t.Errorf("expected %q, got %q", expected, rows)
+	}
+}
@@ -0,0 +1,41 @@
+package appManageAPI
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/platform9/appctl/pkg/appAPIs"
+)
+
+// Protocols of the app port. Knative picks the protocol by the port name,
+// h2c for HTTP/2 without TLS, as gRPC servers need.
+const (
+	ProtocolHTTP1 = "http1"
+	ProtocolH2C   = "h2c"
+)
+
+// To validate the protocol of the app port, and set it in the container
+// options as the port name.
+func SetProtocol(options *appAPIs.ContainerOptions, protocol string) error {
+	if protocol == "" {
+		return nil
+	}
+	protocol = strings.ToLower(protocol)
+	if protocol != ProtocolHTTP1 && protocol != ProtocolH2C {
+		return fmt.Errorf("Invalid --protocol %q, must be %v or %v for HTTP/2 and gRPC apps.", protocol, ProtocolHTTP1, ProtocolH2C)
+	}
+	options.PortName = protocol
+	return nil
+}
+
+// Protocol of the container port, from its name.
+func getProtocol(container map[string]interface{}) string {
+	ports, _ := container["ports"].([]interface{})
+	for _, item := range ports {
+		port, _ := item.(map[string]interface{})
+		if name := stringOf(port["name"]); name == ProtocolH2C || name == ProtocolHTTP1 {
+			return name
+		}
+	}
+	return ProtocolHTTP1
+}
@@ -0,0 +1,61 @@
+package appManageAPI
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/platform9/appctl/pkg/appAPIs"
+)
+
+func TestSetProtocol(t *testing.T) {
+	protocolCases := map[string]struct {
+		protocol     string
+		expectedName string
+		expectedErr  string
+	}{
+		"None":      {},
+		"HTTP1":     {protocol: "http1", expectedName: "http1"},
+		"H2C":       {protocol: "h2c", expectedName: "h2c"},
+		"Uppercase": {protocol: "H2C", expectedName: "h2c"},
+		"GRPC":      {protocol: "grpc", expectedErr: `Invalid --protocol "grpc"`},
+		"HTTP2":     {protocol: "http2", expectedErr: "must be http1 or h2c"},
+	}
+	for testName, test := range protocolCases {
+		var options appAPIs.ContainerOptions
+		err := SetProtocol(&options, test.protocol)
+		if test.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+				t.Errorf("test case %s: expected error %q, got %v", testName, test.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil || options.PortName != test.expectedName {
+			t.Errorf("test case %s: expected port name %q, got %q and error %v", testName, test.expectedName, options.PortName, err)
+		}
+	}
+}
+
+func TestGetPortAndProtocol(t *testing.T) {
+	portCases := map[string]struct {
+		container        map[string]interface{}
+		expectedPort     string
+		expectedProtocol string
+	}{
+		"NoPorts": {container: map[string]interface{}{}, expectedProtocol: "http1"},
+		"Unnamed": {
+			container:        map[string]interface{}{"ports": []interface{}{map[string]interface{}{"containerPort": float64(8080)}}},
+			expectedPort:     "8080",
+			expectedProtocol: "http1",
+		},
+		"H2C": {
+			container:        map[string]interface{}{"ports": []interface{}{map[string]interface{}{"containerPort": float64(50051), "name": "h2c"}}},
+			expectedPort:     "50051",
+			expectedProtocol: "h2c",
+		},
+	}
+	for testName, test := range portCases {
+		if port, protocol := getPort(test.container), getProtocol(test.container); port != test.expectedPort || protocol != test.expectedProtocol {
+			t.Errorf("test case %s: expected port %q and protocol %q, got %q and %q", testName, test.expectedPort, test.expectedProtocol, port, protocol)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package appManageAPI
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	isconnect "github.com/alimasyhur/is-connect"
+	"github.com/platform9/appctl/pkg/appAPIs"
+	"github.com/platform9/appctl/pkg/constants"
+	"github.com/ryanuber/columnize"
+)
+
+// To check the server of a registry, returning its host and optional port.
+func ValidateRegistryServer(server string) (string, error) {
+	server = strings.TrimSpace(server)
+	for _, scheme := range []string{"https://", "http://"} {
+		server = strings.TrimPrefix(server, scheme)
+	}
+	server = strings.TrimSuffix(server, "/")
+	if server == "" || strings.ContainsAny(server, "/ \t@") {
+		return "", fmt.Errorf("Invalid --server %q, use the host of the registry like ghcr.io or registry.example.com:5000.", server)
+	}
+	return server, nil
+}
+
+// To add the credentials of a private registry, for deploy --registry.
+func AddRegistry(registry appAPIs.Registry) error {
+	//Check Internet Connectivity
+	if !isconnect.IsOnline() {
+		return fmt.Errorf("Network unreachable. %v\n", constants.InternetConnectivity)
+	}
+
+	// Load the token, from APPCTL_TOKEN or the config file.
+	token, err := loadToken("add registry")
+	if err != nil {
+		return err
+	}
+
+	event := Event{EventName: "Add-Registry"}
+	if err := appAPIs.CreateRegistry(registry, token); err != nil {
+		//Event is Failure.
+		event.Status = "Failure"
+		event.Error = err.Error()
+		send(event, nil)
+		return fmt.Errorf("Failed to add registry %v with error: %v\n", registry.Name, err)
+	}
+	event.Status = "Success"
+	send(event, nil)
+	fmt.Printf("Registry %v added. Deploy apps from %v with `appctl deploy --registry %v`.\n", registry.Name, registry.Server, registry.Name)
+	return nil
+}
+
+// To list the registries, with the apps using each.
+func ListRegistries() error {
+	//Check Internet Connectivity
+	if !isconnect.IsOnline() {
+		return fmt.Errorf("Network unreachable. %v\n", constants.InternetConnectivity)
+	}
+
+	// Load the token, from APPCTL_TOKEN or the config file.
+	token, err := loadToken("list registries")
+	if err != nil {
+		return err
+	}
+
+	registries, err := appAPIs.ListRegistries(token)
+	if err != nil {
+		return fmt.Errorf("Failed to list registries with error: %v\n", err)
+	}
+	if len(registries) == 0 {
+		fmt.Printf("No registries found, add one by running command `appctl registry add`.\n")
+		return nil
+	}
+	users, err := registryUsers(token)
+	if err != nil {
+		return err
+	}
+
+	output := []string{"NAME | SERVER | USERNAME | APPS"}
+	for _, registry := range registries {
+		output = append(output, fmt.Sprintf("%v | %v | %v | %v",
+			registry.Name, registry.Server, registry.Username, valueOrNone(strings.Join(users[registry.Name], ", "))))
+	}
+	fmt.Println(columnize.SimpleFormat(output))
+	return nil
+}
+
+// To delete the credentials of a registry. Apps still using it fail to pull
+// their image, so it is only deleted for them if force is true.
+func DeleteRegistry(name string, force bool) error {
+	//Check Internet Connectivity
+	if !isconnect.IsOnline() {
+		return fmt.Errorf("Network unreachable. %v\n", constants.InternetConnectivity)
+	}
+
+	// Load the token, from APPCTL_TOKEN or the config file.
+	token, err := loadToken("delete registry")
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		users, err := registryUsers(token)
+		if err != nil {
+			return err
+		}
+		if apps := users[name]; len(apps) > 0 {
+			return fmt.Errorf("Registry %v is used by apps: %v.\nDelete the apps first, or use --force to delete the registry anyway.\n", name, strings.Join(apps, ", "))
+		}
+	}
+
+	event := Event{EventName: "Delete-Registry"}
+	if err := appAPIs.DeleteRegistry(name, token); err != nil {
+		//Event is Failure.
+		event.Status = "Failure"
+		event.Error = err.Error()
+		send(event, nil)
+		return fmt.Errorf("Failed to delete registry %v with error: %v\n", name, err)
+	}
+	event.Status = "Success"
+	send(event, nil)
+	fmt.Printf("Registry %v deleted.\n", name)
+	return nil
+}
+
+// To replace the credentials of a registry, for every app using it. An empty
+// username keeps the current one.
+func RotateRegistry(registry appAPIs.Registry) error {
+	//Check Internet Connectivity
+	if !isconnect.IsOnline() {
+		return fmt.Errorf("Network unreachable. %v\n", constants.InternetConnectivity)
+	}
+
+	// Load the token, from APPCTL_TOKEN or the config file.
+	token, err := loadToken("rotate registry")
+	if err != nil {
+		return err
+	}
+
+	registries, err := appAPIs.ListRegistries(token)
+	if err != nil {
+		return fmt.Errorf("Failed to get registry %v with error: %v\n", registry.Name, err)
+	}
+	var current *appAPIs.Registry
+	for i := range registries {
+		if registries[i].Name == registry.Name {
+			current = &registries[i]
+		}
+	}
+	if current == nil {
+		return fmt.Errorf("Cannot find the registry %v!!\nCheck 'appctl registry list' for the registries added.\n", registry.Name)
+	}
+	registry.Server = current.Server
+	if registry.Username == "" {
+		registry.Username = current.Username
+	}
+
+	event := Event{EventName: "Rotate-Registry"}
+	if err := appAPIs.UpdateRegistry(registry, token); err != nil {
+		//Event is Failure.
+		event.Status = "Failure"
+		event.Error = err.Error()
+		send(event, nil)
+		return fmt.Errorf("Failed to rotate registry %v with error: %v\n", registry.Name, err)
+	}
+	event.Status = "Success"
+	send(event, nil)
+
+	users, err := registryUsers(token)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Registry %v rotated.\n", registry.Name)
+	if apps := users[registry.Name]; len(apps) > 0 {
+		// The pull secret is shared, so the apps don't need to be redeployed.
+		fmt.Printf("Apps using it pull their image with the new credentials from now on: %v\n", strings.Join(apps, ", "))
+	}
+	return nil
+}
+
+// Names of the apps using each registry, from their image pull secrets.
+func registryUsers(token string) (map[string][]string, error) {
+	list_apps, err := appAPIs.ListApps(token)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list apps with error: %v\n", err)
+	}
+	users := map[string][]string{}
+	items, _ := list_apps["items"].([]interface{})
+	for _, item := range items {
+		app, _ := item.(map[string]interface{})
+		metadata, _ := app["metadata"].(map[string]interface{})
+		for _, registry := range appRegistries(app) {
+			users[registry] = append(users[registry], stringOf(metadata["name"]))
+		}
+	}
+	for _, apps := range users {
+		sort.Strings(apps)
+	}
+	return users, nil
+}
+
+// Registries of an app, the image pull secrets of its revision template.
+func appRegistries(get_app map[string]interface{}) []string {
+	spec, _ := get_app["spec"].(map[string]interface{})
+	template, _ := spec["template"].(map[string]interface{})
+	templateSpec, _ := template["spec"].(map[string]interface{})
+	secrets, _ := templateSpec["imagePullSecrets"].([]interface{})
+	var registries []string
+	for _, item := range secrets {
+		secret, _ := item.(map[string]interface{})
+		if name := stringOf(secret["name"]); name != "" {
+			registries = append(registries, name)
+		}
+	}
+	return registries
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+	return value
+}
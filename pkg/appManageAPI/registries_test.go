@@ -0,0 +1,112 @@
+package appManageAPI
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/platform9/appctl/pkg/appAPIs"
+	"github.com/platform9/appctl/pkg/constants"
+)
+
+// To serve a registry and the apps using registries, by their pull secrets.
+func useDummyRegistries(t *testing.T, usedBy map[string]string) {
+	t.Cleanup(func() { constants.REGISTRYURL = "" })
+	constants.REGISTRYURL = "https://api.example.com/v1/registries"
+	httpmock.RegisterResponder(http.MethodGet, constants.REGISTRYURL, httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{
+		"items": []interface{}{map[string]interface{}{"name": "ghcr", "server": "ghcr.io", "username": "jane"}},
+	}))
+	var items []interface{}
+	for app, registry := range usedBy {
+		items = append(items, map[string]interface{}{
+			"metadata": map[string]interface{}{"name": app},
+			"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+				"imagePullSecrets": []interface{}{map[string]interface{}{"name": registry}},
+			}}},
+		})
+	}
+	httpmock.RegisterResponder(http.MethodGet, constants.APPURL, httpmock.NewJsonResponderOrPanic(200, map[string]interface{}{"items": items}))
+}
+
+func TestValidateRegistryServer(t *testing.T) {
+	serverCases := map[string]struct {
+		server   string
+		expected string
+	}{
+		"Host":     {server: "ghcr.io", expected: "ghcr.io"},
+		"Port":     {server: "registry.example.com:5000", expected: "registry.example.com:5000"},
+		"Scheme":   {server: "https://ghcr.io/", expected: "ghcr.io"},
+		"Empty":    {server: " "},
+		"Path":     {server: "ghcr.io/org/image"},
+		"UserInfo": {server: "jane@ghcr.io"},
+	}
+	for testName, test := range serverCases {
+		server, err := ValidateRegistryServer(test.server)
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("test case %s: expected an error, got %q", testName, server)
+			}
+			continue
+		}
+		if err != nil || server != test.expected {
+			t.Errorf("test case %s: expected %q, got %q and error %v", testName, test.expected, server, err)
+		}
+	}
+}
+
+func TestRegistryUsers(t *testing.T) {
+	useDummyLogin(t)
+	useDummyRegistries(t, map[string]string{"web": "ghcr", "worker": "ghcr", "api": "ecr"})
+	users, err := registryUsers("token")
+	if err != nil {
+		t.Fatalf("failed with error: %v", err)
+	}
+	if strings.Join(users["ghcr"], " ") != "web worker" || strings.Join(users["ecr"], " ") != "api" {
+		t.Errorf("expected ghcr used by web and worker and ecr by api, got %v", users)
+	}
+}
+
+func TestRotateRegistry(t *testing.T) {
+	useDummyLogin(t)
+	useDummyRegistries(t, map[string]string{"web": "ghcr"})
+	var updated appAPIs.Registry
+	httpmock.RegisterResponder(http.MethodPut, constants.REGISTRYURL+"/ghcr", func(req *http.Request) (*http.Response, error) {
+		json.NewDecoder(req.Body).Decode(&updated)
+		return httpmock.NewStringResponse(200, ""), nil
+	})
+
+	if err := RotateRegistry(appAPIs.Registry{Name: "ghcr", Password: "new-token"}); err != nil {
+		t.Fatalf("failed with error: %v", err)
+	}
+	// The server and username are kept.
+	expected := appAPIs.Registry{Name: "ghcr", Server: "ghcr.io", Username: "jane", Password: "new-token"}
+	if updated != expected {
+		t.Errorf("expected %+v, got %+v", expected, updated)
+	}
+
+	if err := RotateRegistry(appAPIs.Registry{Name: "ecr", Password: "token"}); err == nil || !strings.Contains(err.Error(), "Cannot find the registry ecr") {
+		t.Errorf("expected a missing registry error, got %v", err)
+	}
+}
+
+func TestDeleteRegistryInUse(t *testing.T) {
+	useDummyLogin(t)
+	useDummyRegistries(t, map[string]string{"web": "ghcr"})
+	deleted := 0
+	httpmock.RegisterResponder(http.MethodDelete, constants.REGISTRYURL+"/ghcr", func(req *http.Request) (*http.Response, error) {
+		deleted++
+		return httpmock.NewStringResponse(200, ""), nil
+	})
+
+	if err := DeleteRegistry("ghcr", false); err == nil || !strings.Contains(err.Error(), "used by apps: web") {
+		t.Errorf("expected an in use error, got %v", err)
+	}
+	if deleted != 0 {
+		t.Errorf("expected the registry in use not to be deleted")
+	}
+	if err := DeleteRegistry("ghcr", true); err != nil || deleted != 1 {
+		t.Errorf("expected the registry to be deleted with force, got %v", err)
+	}
+}
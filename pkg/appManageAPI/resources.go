@@ -0,0 +1,130 @@
+package appManageAPI
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+
+	"github.com/platform9/appctl/pkg/appAPIs"
+)
+
+// Kubernetes quantity: a number with an optional SI, binary or exponent suffix.
+var quantityRegex = regexp.MustCompile(`^([0-9]+(?:\.[0-9]*)?|\.[0-9]+)([a-zA-Z]*|[eE][+-]?[0-9]+)$`)
+
+// Multipliers of the quantity suffixes.
+var quantitySuffixes = map[string]float64{
+	"":   1,
+	"m":  1e-3,
+	"k":  1e3,
+	"M":  1e6,
+	"G":  1e9,
+	"T":  1e12,
+	"P":  1e15,
+	"E":  1e18,
+	"Ki": 1 << 10,
+	"Mi": 1 << 20,
+	"Gi": 1 << 30,
+	"Ti": 1 << 40,
+	"Pi": 1 << 50,
+	"Ei": 1 << 60,
+}
+
+// To parse a Kubernetes quantity like 500m, 0.5, 512Mi or 1G into its value
+// and suffix.
+func parseQuantity(quantity string) (float64, string, error) {
+	match := quantityRegex.FindStringSubmatch(quantity)
+	if match == nil {
+		return 0, "", fmt.Errorf("invalid quantity %q", quantity)
+	}
+	number, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid quantity %q", quantity)
+	}
+	suffix := match[2]
+	if multiplier, found := quantitySuffixes[suffix]; found {
+		return number * multiplier, suffix, nil
+	}
+	// Exponent, eg. 1e3.
+	if suffix[0] != 'e' && suffix[0] != 'E' {
+		return 0, "", fmt.Errorf("invalid quantity %q, unknown suffix %q", quantity, suffix)
+	}
+	exponent, err := strconv.Atoi(suffix[1:])
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid quantity %q, unknown suffix %q", quantity, suffix)
+	}
+	return number * math.Pow10(exponent), suffix, nil
+}
+
+// To check a cpu quantity, in cores like 0.5 or millicores like 500m.
+func validateCPU(flag string, cpu string) (float64, error) {
+	value, suffix, err := parseQuantity(cpu)
+	if err != nil || value <= 0 || (suffix != "" && suffix != "m") {
+		return 0, fmt.Errorf("Invalid --%s %q, use cores like 0.5 or millicores like 500m.", flag, cpu)
+	}
+	return value, nil
+}
+
+// To check a memory quantity, in bytes with a suffix like 512Mi or 1G.
+func validateMemory(flag string, memory string) (float64, error) {
+	value, suffix, err := parseQuantity(memory)
+	if err == nil && suffix == "m" {
+		// Valid for Kubernetes but thousandths of a byte, always a typo.
+		return 0, fmt.Errorf("Invalid --%s %q, m means thousandths of a byte, did you mean %sMi?", flag, memory, memory[:len(memory)-1])
+	}
+	if err != nil || value < 1 {
+		return 0, fmt.Errorf("Invalid --%s %q, use bytes with a suffix like 512Mi or 1Gi.", flag, memory)
+	}
+	return value, nil
+}
+
+// To build the resources of the app container from the flags of deploy,
+// after validating them. Returns nil if none are set.
+func NewResources(cpu string, memory string, cpuLimit string, memoryLimit string) (*appAPIs.Resources, error) {
+	if cpu == "" && memory == "" && cpuLimit == "" && memoryLimit == "" {
+		return nil, nil
+	}
+
+	resources := &appAPIs.Resources{Requests: map[string]string{}, Limits: map[string]string{}}
+	values := map[string]float64{}
+	quantities := []struct {
+		flag     string
+		value    string
+		resource string
+		list     map[string]string
+		validate func(string, string) (float64, error)
+	}{
+		{"cpu", cpu, "cpu", resources.Requests, validateCPU},
+		{"memory", memory, "memory", resources.Requests, validateMemory},
+		{"cpu-limit", cpuLimit, "cpu", resources.Limits, validateCPU},
+		{"memory-limit", memoryLimit, "memory", resources.Limits, validateMemory},
+	}
+	for _, quantity := range quantities {
+		if quantity.value == "" {
+			continue
+		}
+		value, err := quantity.validate(quantity.flag, quantity.value)
+		if err != nil {
+			return nil, err
+		}
+		values[quantity.flag] = value
+		quantity.list[quantity.resource] = quantity.value
+	}
+
+	// Requests above the limit are rejected by Kubernetes.
+	for _, resource := range []string{"cpu", "memory"} {
+		request, hasRequest := values[resource]
+		limit, hasLimit := values[resource+"-limit"]
+		if hasRequest && hasLimit && request > limit {
+			return nil, fmt.Errorf("--%s %v is more than --%s-limit %v.", resource, resources.Requests[resource], resource, resources.Limits[resource])
+		}
+	}
+
+	if len(resources.Requests) == 0 {
+		resources.Requests = nil
+	}
+	if len(resources.Limits) == 0 {
+		resources.Limits = nil
+	}
+	return resources, nil
+}
@@ -0,0 +1,87 @@
+package appManageAPI
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNewResources(t *testing.T) {
+	resourceCases := map[string]struct {
+		cpu, memory, cpuLimit, memoryLimit string
+		expectedRequests                   string
+		expectedLimits                     string
+		expectedErr                        string
+	}{
+		"None":           {},
+		"Millicores":     {cpu: "250m", expectedRequests: "map[cpu:250m]"},
+		"Cores":          {cpu: "0.5", cpuLimit: "2", expectedRequests: "map[cpu:0.5]", expectedLimits: "map[cpu:2]"},
+		"Memory":         {memory: "512Mi", memoryLimit: "1Gi", expectedRequests: "map[memory:512Mi]", expectedLimits: "map[memory:1Gi]"},
+		"MemoryDecimal":  {memory: "1.5G", expectedRequests: "map[memory:1.5G]"},
+		"MemoryExponent": {memory: "129e6", expectedRequests: "map[memory:129e6]"},
+		"OnlyLimits":     {cpuLimit: "1", memoryLimit: "256Mi", expectedLimits: "map[cpu:1 memory:256Mi]"},
+		"All": {cpu: "100m", memory: "128Mi", cpuLimit: "1", memoryLimit: "1Gi",
+			expectedRequests: "map[cpu:100m memory:128Mi]", expectedLimits: "map[cpu:1 memory:1Gi]"},
+		"InvalidCPU":           {cpu: "half", expectedErr: "Invalid --cpu"},
+		"CPUWithMemorySuffix":  {cpu: "512Mi", expectedErr: "Invalid --cpu"},
+		"ZeroCPU":              {cpu: "0", expectedErr: "Invalid --cpu"},
+		"InvalidMemory":        {memory: "lots", expectedErr: "Invalid --memory"},
+		"MemoryMillis":         {memory: "512m", expectedErr: "did you mean 512Mi?"},
+		"InvalidMemoryLimit":   {memoryLimit: "1GB", expectedErr: "Invalid --memory-limit"},
+		"NegativeCPULimit":     {cpuLimit: "-1", expectedErr: "Invalid --cpu-limit"},
+		"CPURequestOverLimit":  {cpu: "1500m", cpuLimit: "1", expectedErr: "--cpu 1500m is more than --cpu-limit 1"},
+		"MemoryRequestOverLim": {memory: "2Gi", memoryLimit: "1500Mi", expectedErr: "--memory 2Gi is more than --memory-limit 1500Mi"},
+	}
+
+	for testName, test := range resourceCases {
+		resources, err := NewResources(test.cpu, test.memory, test.cpuLimit, test.memoryLimit)
+		if test.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+				t.Errorf("test case %s: expected error %q, got %v", testName, test.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test case %s: failed with error: %v", testName, err)
+			continue
+		}
+		if test.expectedRequests == "" && test.expectedLimits == "" {
+			if resources != nil {
+				t.Errorf("test case %s: expected no resources, got %+v", testName, resources)
+			}
+			continue
+		}
+		if fmt.Sprint(resources.Requests) != orEmptyMap(test.expectedRequests) || fmt.Sprint(resources.Limits) != orEmptyMap(test.expectedLimits) {
+			t.Errorf("test case %s: expected requests %s and limits %s, got %+v", testName, test.expectedRequests, test.expectedLimits, resources)
+		}
+	}
+}
+
+func orEmptyMap(expected string) string {
+	if expected == "" {
+		return "map[]"
+	}
+	return expected
+}
+
+func TestResourceRows(t *testing.T) {
+	container := map[string]interface{}{
+		"image": "nginx",
+		"resources": map[string]interface{}{
+			"requests": map[string]interface{}{"cpu": "250m", "memory": "512Mi"},
+			"limits":   map[string]interface{}{"memory": "1Gi"},
+		},
+	}
+	expected := []string{
+		"CPU request: | 250m",
+		"CPU limit: | " + notSet,
+		"Memory request: | 512Mi",
+		"Memory limit: | 1Gi",
+	}
+	if rows := resourceRows(container); fmt.Sprint(rows) != fmt.Sprint(expected) {
+		t.Errorf("expected %q, got %q", expected, rows)
+	}
+	if rows := resourceRows(nil); !strings.Contains(rows[0], notSet) {
+		t.Errorf("expected server defaults without resources, got %q", rows)
+	}
+}
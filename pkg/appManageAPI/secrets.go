@@ -0,0 +1,149 @@
+package appManageAPI
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/platform9/appctl/pkg/appAPIs"
+	"github.com/platform9/appctl/pkg/constants"
+	"github.com/platform9/appctl/pkg/secrets"
+)
+
+// Values of the secrets of the app being deployed, masked in the errors
+// printed, and always in usage events and the audit log.
+var secretValues []string
+
+// To warn about the environment variables of an app that look like secrets,
+// as they are sent to the server and stored as plain text. Their values, and
+// the registry password, are masked from then on.
+func WarnSecrets(env map[string]string, password string) {
+	if password != "" {
+		secretValues = append(secretValues, password)
+	}
+	for _, finding := range secrets.Scan(env) {
+		secretValues = append(secretValues, env[finding.Key])
+		fmt.Printf("Warning: environment variable %v looks like a secret (%v). It is stored in the app as plain text.\n", finding.Key, finding.Reason)
+	}
+}
+
+// To resolve the env values of the app being deployed that reference secrets,
+// like @file:/run/secrets/db or @vault:secret/data/app#password. The -e pairs
+// are resolved, the values of the env file only if resolveFile is set, as a
+// checked in file could run commands with @cmd:, and its values starting
+// with @ were always literal. Returns all of them as sorted key=value pairs,
+// the values resolved are masked from then on.
+func ResolveEnv(env []string, envFilePath string, resolveFile bool) ([]string, error) {
+	fileValues := map[string]string{}
+	if envFilePath != "" {
+		_, envMap, err := appAPIs.GetSliceFromEnvFile(envFilePath)
+		if err != nil {
+			return nil, fmt.Errorf("%v\n", err)
+		}
+		fileValues = envMap
+	}
+	// Values kept as is, and values that can reference secrets.
+	literal, references := fileValues, map[string]string{}
+	if resolveFile {
+		literal, references = map[string]string{}, fileValues
+	}
+	for _, pair := range env {
+		split := strings.SplitN(pair, "=", 2)
+		if len(split) != 2 {
+			return nil, fmt.Errorf("Invalid environment variable %v, use key=value.\n", pair)
+		}
+		_, inFile := literal[split[0]]
+		if _, ok := references[split[0]]; ok || inFile {
+			return nil, fmt.Errorf("Duplicate environment variable: %v found. Either remove it from env file or from command line.\n", split[0])
+		}
+		references[split[0]] = split[1]
+	}
+
+	var resolved []string
+	for key, value := range literal {
+		resolved = append(resolved, key+"="+value)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), constants.SECRETRESOLVETIMEOUT*time.Second)
+	defer cancel()
+	for key, value := range references {
+		value, isReference, err := secrets.Resolve(ctx, value)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to resolve environment variable %v with error: %v.\n", key, err)
+		}
+		if isReference {
+			secretValues = append(secretValues, value)
+		}
+		resolved = append(resolved, key+"="+value)
+	}
+	sort.Strings(resolved)
+	return resolved, nil
+}
+
+// To mask the values of the secrets of the app being deployed in text.
+func MaskSecrets(text string) string {
+	return secrets.Redact(text, secretValues)
+}
+
+// To mask the events sent and recorded, the server errors can include the
+// request.
+func maskEvent(event Event) Event {
+	event.Error = MaskSecrets(event.Error)
+	if event.Data == nil {
+		return event
+	}
+	data := make([]constants.ListAppInfo, len(event.Data))
+	for i, info := range event.Data {
+		info.Reason = MaskSecrets(info.Reason)
+		data[i] = info
+	}
+	event.Data = data
+	return event
+}
+
+// To mask the values of the environment variables of the containers of an
+// app that look like secrets. The app is copied, not changed.
+func maskAppSecrets(get_app map[string]interface{}) map[string]interface{} {
+	data, err := json.Marshal(get_app)
+	if err != nil {
+		return get_app
+	}
+	masked := map[string]interface{}{}
+	if err := json.Unmarshal(data, &masked); err != nil {
+		return get_app
+	}
+	spec, _ := masked["spec"].(map[string]interface{})
+	template, _ := spec["template"].(map[string]interface{})
+	templateSpec, _ := template["spec"].(map[string]interface{})
+	containers, _ := templateSpec["containers"].([]interface{})
+	for _, item := range containers {
+		container, _ := item.(map[string]interface{})
+		env, _ := container["env"].([]interface{})
+		for _, item := range env {
+			variable, _ := item.(map[string]interface{})
+			value, ok := variable["value"].(string)
+			if ok && secrets.Detect(stringOf(variable["name"]), value) != "" {
+				variable["value"] = secrets.Mask
+			}
+		}
+	}
+	return masked
+}
+
+// Environment variables of the container as key=value pairs, sorted.
+func describeEnv(container map[string]interface{}) string {
+	env, _ := container["env"].([]interface{})
+	var pairs []string
+	for _, item := range env {
+		variable, _ := item.(map[string]interface{})
+		if _, ok := variable["valueFrom"]; ok {
+			pairs = append(pairs, fmt.Sprintf("%v=<from reference>", stringOf(variable["name"])))
+			continue
+		}
+		pairs = append(pairs, fmt.Sprintf("%v=%v", stringOf(variable["name"]), stringOf(variable["value"])))
+	}
+	sort.Strings(pairs)
+	return valueOrNone(strings.Join(pairs, ", "))
+}
@@ -0,0 +1,50 @@
+package appManageAPI
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"time"
+
+	"github.com/platform9/appctl/pkg/constants"
+	"github.com/platform9/appctl/pkg/registry"
+)
+
+// To read the public key to verify image signatures with, like cosign.pub.
+func LoadSignatureKey(path string) (*ecdsa.PublicKey, error) {
+	key, err := registry.LoadPublicKey(path)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid key %v: %v.\n", path, err)
+	}
+	return key, nil
+}
+
+// To verify the image is signed with key by cosign, before deploying it.
+// digest is the one the image resolved to, it is resolved here if empty.
+// Returns the digest verified, the one to deploy.
+func VerifyImageSignature(image string, digest string, key *ecdsa.PublicKey, username string, password string) (string, error) {
+	ref, err := registry.ParseReference(image)
+	if err != nil {
+		return "", fmt.Errorf("Invalid image: %v.\n", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), constants.IMAGECHECKTIMEOUT*time.Second)
+	defer cancel()
+	client := registry.Client{Username: username, Password: password}
+
+	if digest == "" {
+		digest = ref.Digest
+	}
+	if digest == "" {
+		manifest, err := client.Manifest(ctx, ref, ref.Tag)
+		if err != nil {
+			return "", fmt.Errorf("Couldn't resolve image %v to verify its signature: %v.\n", image, err)
+		}
+		digest = manifest.Digest
+	}
+
+	if err := client.VerifySignature(ctx, ref, digest, key); err != nil {
+		return "", fmt.Errorf("Image %v can't be deployed, its signature couldn't be verified: %v.\n", image, err)
+	}
+	fmt.Printf("Verified the signature of %v.\n", image)
+	return digest, nil
+}
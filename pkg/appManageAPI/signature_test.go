@@ -0,0 +1,54 @@
+package appManageAPI
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVerifyImageSignature(t *testing.T) {
+	// A registry where the v1 tag points to deployedDigest, which isn't signed.
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		if r.URL.Path == "/v2/org/app/manifests/v1" {
+			w.Header().Set("Docker-Content-Digest", deployedDigest)
+			w.Write([]byte(`{"schemaVersion":2}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errors":[{"code":"MANIFEST_UNKNOWN"}]}`))
+	}))
+	defer server.Close()
+	repository := strings.TrimPrefix(server.URL, "http://") + "/org/app"
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	signatureCases := map[string]struct {
+		image             string
+		digest            string
+		expectedRequested string
+	}{
+		// The digest is resolved when the image wasn't checked before.
+		"Tag":      {image: repository + ":v1", expectedRequested: "/v2/org/app/manifests/v1,/v2/org/app/manifests/sha256-1111"},
+		"Resolved": {image: repository + ":v1", digest: deployedDigest, expectedRequested: "/v2/org/app/manifests/sha256-1111"},
+		"ByDigest": {image: repository + "@" + deployedDigest, expectedRequested: "/v2/org/app/manifests/sha256-1111"},
+	}
+	for testName, test := range signatureCases {
+		requested = nil
+		_, err := VerifyImageSignature(test.image, test.digest, &key.PublicKey, "", "")
+		if err == nil || !strings.Contains(err.Error(), "isn't signed") {
+			t.Errorf("test case %s: expected an unsigned image error, got %v", testName, err)
+		}
+		if got := strings.Join(requested, ","); !strings.HasPrefix(got, test.expectedRequested) || len(requested) != strings.Count(test.expectedRequested, ",")+1 {
+			t.Errorf("test case %s: expected requests %v, got %v", testName, test.expectedRequested, got)
+		}
+	}
+
+	if _, err := LoadSignatureKey("missing/cosign.pub"); err == nil || !strings.Contains(err.Error(), "Invalid key") {
+		t.Errorf("test case MissingKey: expected an invalid key error, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package appManageAPI
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/platform9/appctl/pkg/constants"
+)
+
+// TokenSource supplies the token used to authenticate with the app-controller.
+type TokenSource interface {
+	// Token returns a valid, unexpired token.
+	Token() (string, error)
+}
+
+// Errors returned by token sources when the user has to login.
+var (
+	errNotLoggedIn  = errors.New("Not logged in")
+	errLoginExpired = errors.New("Login expired")
+)
+
+// Token set in the APPCTL_TOKEN environment variable, used by CI runners
+// that can't complete the browser login.
+type envTokenSource struct {
+	token string
+}
+
+func (e envTokenSource) Token() (string, error) {
+	expired, err := checkTokenExpired(e.token)
+	if err != nil {
+		return "", fmt.Errorf("Token in %s is invalid: %v", constants.TOKENENVVAR, err)
+	}
+	if expired {
+		return "", fmt.Errorf("Token in %s is expired.", constants.TOKENENVVAR)
+	}
+	return e.token, nil
+}
+
+// Token saved in the config file by `appctl login`.
+type configTokenSource struct {
+	configFilePath string
+}
+
+func (c configTokenSource) Token() (string, error) {
+	config, err := loadConfig(c.configFilePath)
+	if err != nil || config.IDToken == "" {
+		return "", errNotLoggedIn
+	}
+
+	// Check if Token is expired or not.
+	expired, _ := checkTokenExpired(config.IDToken)
+	if expired {
+		return "", errLoginExpired
+	}
+	return config.IDToken, nil
+}
+
+// To get the token source in use, APPCTL_TOKEN overrides the config file.
+func currentTokenSource() TokenSource {
+	if token := os.Getenv(constants.TOKENENVVAR); token != "" {
+		return envTokenSource{token: token}
+	}
+	return configTokenSource{configFilePath: constants.CONFIGFILEPATH}
+}
+
+// To get the token in use, even if it is expired, to tell who the events are
+// of. Empty if there is none.
+func savedToken() string {
+	if token := os.Getenv(constants.TOKENENVVAR); token != "" {
+		return token
+	}
+	config, err := loadConfig(constants.CONFIGFILEPATH)
+	if err != nil {
+		return ""
+	}
+	return config.IDToken
+}
+
+// To get the token for an app-controller request, action is used in the
+// error message, eg. "list apps".
+func loadToken(action string) (string, error) {
+	token, err := currentTokenSource().Token()
+	switch err {
+	case nil:
+		return token, nil
+	case errNotLoggedIn:
+		return "", fmt.Errorf("Failed to %s. Please login using command `appctl login`.\n", action)
+	case errLoginExpired:
+		return "", fmt.Errorf("Login expired. Please login again using command `appctl login`\n")
+	default:
+		return "", fmt.Errorf("Failed to %s. %v\n", action, err)
+	}
+}
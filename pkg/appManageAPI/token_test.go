@@ -0,0 +1,247 @@
+package appManageAPI
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/jarcoal/httpmock"
+	"github.com/platform9/appctl/pkg/constants"
+	"github.com/platform9/appctl/pkg/jwks"
+)
+
+const (
+	dummyIssuer   = "https://appctl.test/"
+	dummyClientID = "dummyClientID"
+	dummyKeyID    = "dummyKey"
+)
+
+// Starts a local stand-in for the issuer's JWKS endpoint, and points
+// token verification at it. Returns the key tokens should be signed with.
+func useDummyIssuer(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []jwks.JSONWebKey{{
+				Kid: dummyKeyID,
+				Kty: "RSA",
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+
+	savedKeySet, savedIssuer, savedClientID := keySet, constants.ISSUER, constants.CLIENTID
+	keySet = jwks.NewKeySet(server.URL, "")
+	// Own transport, so the stand-in is reachable while httpmock is active.
+	keySet.Client = &http.Client{Transport: &http.Transport{}}
+	constants.ISSUER, constants.CLIENTID = dummyIssuer, dummyClientID
+	t.Cleanup(func() {
+		server.Close()
+		keySet, constants.ISSUER, constants.CLIENTID = savedKeySet, savedIssuer, savedClientID
+	})
+	return key
+}
+
+// Valid claims for a token issued to appctl, callers override what they test.
+func dummyClaims(overrides jwt.MapClaims) jwt.MapClaims {
+	claims := jwt.MapClaims{
+		"iss":   dummyIssuer,
+		"aud":   dummyClientID,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"email": "user@appctl.test",
+	}
+	for key, value := range overrides {
+		if value == nil {
+			delete(claims, key)
+			continue
+		}
+		claims[key] = value
+	}
+	return claims
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = dummyKeyID
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestGetTokenClaims(t *testing.T) {
+	key := useDummyIssuer(t)
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	unsigned, _ := jwt.NewWithClaims(jwt.SigningMethodNone, dummyClaims(nil)).SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+	tokenCases := map[string]struct {
+		token       string
+		expectValid bool
+	}{
+		"Valid":           {token: signToken(t, key, dummyClaims(nil)), expectValid: true},
+		"AudienceList":    {token: signToken(t, key, dummyClaims(jwt.MapClaims{"aud": []string{"other", dummyClientID}})), expectValid: true},
+		"WrongSignature":  {token: signToken(t, otherKey, dummyClaims(nil))},
+		"Unsigned":        {token: unsigned},
+		"WrongIssuer":     {token: signToken(t, key, dummyClaims(jwt.MapClaims{"iss": "https://evil.test/"}))},
+		"WrongAudience":   {token: signToken(t, key, dummyClaims(jwt.MapClaims{"aud": "otherClient"}))},
+		"NotYetValid":     {token: signToken(t, key, dummyClaims(jwt.MapClaims{"nbf": time.Now().Add(time.Hour).Unix()}))},
+		"MissingExpiry":   {token: signToken(t, key, dummyClaims(jwt.MapClaims{"exp": nil}))},
+		"Expired":         {token: signToken(t, key, dummyClaims(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}))},
+		"MalformedString": {token: "wizK8eib75MNuw=="},
+	}
+
+	for testName, test := range tokenCases {
+		claims, err := getTokenClaims(test.token)
+		if test.expectValid && err != nil {
+			t.Errorf("test case %s: expected valid token, got error: %v", testName, err)
+		}
+		if !test.expectValid && err == nil {
+			t.Errorf("test case %s: expected invalid token, got claims: %v", testName, claims)
+		}
+	}
+}
+
+func TestCheckTokenExpired(t *testing.T) {
+	key := useDummyIssuer(t)
+
+	expired, err := checkTokenExpired(signToken(t, key, dummyClaims(nil)))
+	if expired || err != nil {
+		t.Errorf("expected token to be valid, got expired: %v, error: %v", expired, err)
+	}
+
+	expired, err = checkTokenExpired(signToken(t, key, dummyClaims(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()})))
+	if !expired || err != nil {
+		t.Errorf("expected token to be expired, got expired: %v, error: %v", expired, err)
+	}
+
+	expired, err = checkTokenExpired(signToken(t, key, dummyClaims(jwt.MapClaims{"iss": "https://evil.test/"})))
+	if !expired || err == nil {
+		t.Errorf("expected forged token to be rejected, got expired: %v, error: %v", expired, err)
+	}
+
+	// Expired tokens of another issuer or client are invalid, not expired.
+	past := time.Now().Add(-time.Hour).Unix()
+	for _, claims := range []jwt.MapClaims{{"iss": "https://evil.test/", "exp": past}, {"aud": "otherClient", "exp": past}} {
+		if _, err := getTokenClaims(signToken(t, key, dummyClaims(claims))); err == nil || err == errTokenExpired {
+			t.Errorf("expected expired forged token %v to be rejected, got error: %v", claims, err)
+		}
+	}
+}
+
+// Points the config file at a temporary directory.
+func useTempConfig(t *testing.T) {
+	savedConfigDir, savedConfigFilePath := constants.CONFIGDIR, constants.CONFIGFILEPATH
+	constants.CONFIGDIR = t.TempDir()
+	constants.CONFIGFILEPATH = filepath.Join(constants.CONFIGDIR, "config.json")
+	t.Cleanup(func() {
+		constants.CONFIGDIR, constants.CONFIGFILEPATH = savedConfigDir, savedConfigFilePath
+	})
+}
+
+func TestLoadToken(t *testing.T) {
+	key := useDummyIssuer(t)
+	useTempConfig(t)
+	configToken := signToken(t, key, dummyClaims(jwt.MapClaims{"email": "config@appctl.test"}))
+	envToken := signToken(t, key, dummyClaims(jwt.MapClaims{"email": "env@appctl.test"}))
+	expiredToken := signToken(t, key, dummyClaims(jwt.MapClaims{"email": "expired@appctl.test", "exp": time.Now().Add(-time.Hour).Unix()}))
+
+	// Not logged in.
+	t.Setenv(constants.TOKENENVVAR, "")
+	if _, err := loadToken("list apps"); err == nil || !strings.Contains(err.Error(), "Please login") {
+		t.Errorf("expected login error, got: %v", err)
+	}
+
+	// Logged in with an expired token.
+	createConfig(Config{IDToken: expiredToken}, constants.CONFIGFILEPATH)
+	if _, err := loadToken("list apps"); err == nil || !strings.Contains(err.Error(), "Login expired") {
+		t.Errorf("expected login expired error, got: %v", err)
+	}
+	// Failures after the login expired are still of the user.
+	if userId, _, err := fetchUserId(); err != nil || userId != "expired@appctl.test" {
+		t.Errorf("expected user of the expired token, got %q with error: %v", userId, err)
+	}
+
+	// Logged in.
+	createConfig(Config{IDToken: configToken}, constants.CONFIGFILEPATH)
+	if token, err := loadToken("list apps"); err != nil || token != configToken {
+		t.Errorf("expected token from config, got: %v", err)
+	}
+
+	// APPCTL_TOKEN overrides the config file.
+	t.Setenv(constants.TOKENENVVAR, envToken)
+	if token, err := loadToken("list apps"); err != nil || token != envToken {
+		t.Errorf("expected token from %s, got: %v", constants.TOKENENVVAR, err)
+	}
+	if userId, _, err := fetchUserId(); err != nil || userId != "env@appctl.test" {
+		t.Errorf("expected user from %s, got %q with error: %v", constants.TOKENENVVAR, userId, err)
+	}
+
+	// An invalid APPCTL_TOKEN is an error, not a fallback to the config file.
+	t.Setenv(constants.TOKENENVVAR, expiredToken)
+	if _, err := loadToken("list apps"); err == nil || !strings.Contains(err.Error(), constants.TOKENENVVAR) {
+		t.Errorf("expected %s error, got: %v", constants.TOKENENVVAR, err)
+	}
+}
+
+func TestLoginClientCredentials(t *testing.T) {
+	key := useDummyIssuer(t)
+	useTempConfig(t)
+	t.Setenv(constants.TOKENENVVAR, "")
+	savedDomain, savedAudience := constants.DOMAIN, constants.AUDIENCE
+	constants.DOMAIN, constants.AUDIENCE = dummyDomain, "https://api.appctl.test"
+	t.Cleanup(func() {
+		constants.DOMAIN, constants.AUDIENCE = savedDomain, savedAudience
+	})
+
+	accessToken := signToken(t, key, dummyClaims(jwt.MapClaims{
+		"aud":   constants.AUDIENCE,
+		"sub":   "ciClient@clients",
+		"gty":   "client-credentials",
+		"email": nil,
+	}))
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder(http.MethodGet, "https://google.com", httpmock.NewStringResponder(200, ""))
+	httpmock.RegisterResponder(http.MethodPost, constants.APPURL+"/login", httpmock.NewStringResponder(200, ""))
+	httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("https://%s/oauth/token", dummyDomain), func(req *http.Request) (*http.Response, error) {
+		req.ParseForm()
+		if req.PostForm.Get("grant_type") != "client_credentials" || req.PostForm.Get("audience") != constants.AUDIENCE {
+			t.Errorf("unexpected token request: %v", req.PostForm)
+		}
+		if req.PostForm.Get("client_id") != "ciClient" || req.PostForm.Get("client_secret") != "ciSecret" {
+			return httpmock.NewJsonResponse(401, map[string]string{"error": "access_denied", "error_description": "Unauthorized"})
+		}
+		return httpmock.NewJsonResponse(200, map[string]interface{}{"access_token": accessToken, "expires_in": 86400, "token_type": "Bearer"})
+	})
+
+	if err := LoginClientCredentials("ciClient", "wrongSecret"); err == nil {
+		t.Errorf("expected login with wrong secret to fail")
+	}
+
+	if err := LoginClientCredentials("ciClient", "ciSecret"); err != nil {
+		t.Fatalf("failed with error: %v", err)
+	}
+	if token, err := loadToken("list apps"); err != nil || token != accessToken {
+		t.Errorf("expected client credentials token to be saved, got: %v", err)
+	}
+	if userId, loginType, _ := fetchUserId(); userId != "ciClient@clients" || loginType != "client-credentials" {
+		t.Errorf("unexpected identity %q, %q", userId, loginType)
+	}
+}
@@ -0,0 +1,162 @@
+package appManageAPI
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	isconnect "github.com/alimasyhur/is-connect"
+	"github.com/briandowns/spinner"
+	"github.com/platform9/appctl/pkg/appAPIs"
+	"github.com/platform9/appctl/pkg/constants"
+)
+
+// To start the loopback listener the browser redirects to, replaced in tests.
+var listenLoopback = func() (net.Listener, error) {
+	port := constants.LOGINREDIRECTPORT
+	if port == "" {
+		port = "0"
+	}
+	return net.Listen("tcp", net.JoinHostPort("127.0.0.1", port))
+}
+
+// Result of the redirect to the loopback listener.
+type authorizationResponse struct {
+	code string
+	err  error
+}
+
+// To login using Authorization Code with PKCE, with the browser redirecting
+// back to a listener on localhost. Falls back to the device flow when the
+// listener can't be started.
+func LoginAppWeb() error {
+	//Check Internet Connectivity
+	if !isconnect.IsOnline() {
+		return fmt.Errorf("Network unreachable. %v\n", constants.InternetConnectivity)
+	}
+
+	listener, err := listenLoopback()
+	if err != nil {
+		fmt.Printf("Unable to start the local login listener: %v\nUsing device login instead.\n", err)
+		return LoginApp(false)
+	}
+	defer listener.Close()
+
+	codeVerifier, err := randomURLString(32)
+	if err != nil {
+		return fmt.Errorf("Unable to generate code verifier.\nError: %v\n", err)
+	}
+	state, err := randomURLString(16)
+	if err != nil {
+		return fmt.Errorf("Unable to generate login state.\nError: %v\n", err)
+	}
+
+	redirectURI := fmt.Sprintf("http://%s/callback", listener.Addr().String())
+	responses := make(chan authorizationResponse, 1)
+	server := &http.Server{Handler: callbackHandler(state, responses)}
+	go server.Serve(listener)
+	defer server.Close()
+
+	fmt.Printf("Starting login process.\n")
+	authURL := authorizeURL(redirectURI, codeChallenge(codeVerifier), state)
+	err = openBrowser(authURL)
+	if err != nil {
+		fmt.Printf("\nCouldn't open the URL, kindly do it manually: %v\n", authURL)
+	}
+
+	s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
+	s.Color("red")
+	s.Suffix = " Waiting for login to complete in browser..."
+	s.Start()
+
+	// Send Segment Event
+	var event Event
+	var response authorizationResponse
+	select {
+	case response = <-responses:
+	case <-time.After(constants.WEBLOGINTIMEOUT * time.Second):
+		response.err = fmt.Errorf("timed out waiting for the browser")
+	}
+
+	var Token *appAPIs.TokenInfo
+	if response.err == nil {
+		Token, response.err = appAPIs.RequestAuthorizationCodeToken(response.code, codeVerifier, redirectURI)
+	}
+	s.Stop()
+	if response.err != nil {
+		//Event is Failure.
+		event.EventName = "Login"
+		event.Status = "Failure"
+		event.Error = response.err.Error()
+		send(event, nil)
+		return fmt.Errorf("\nCannot login. Please try again.\nError: %v\n", response.err)
+	}
+
+	return saveLogin(Token.IdToken, Token.ExpiresIn)
+}
+
+// Handler for the redirect from the authorization server, passes the
+// authorization code or the error on to the login flow.
+func callbackHandler(state string, responses chan<- authorizationResponse) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("state") != state {
+			// Not a response to our request, could be forged. Keep waiting.
+			http.Error(w, "Invalid login state.", http.StatusBadRequest)
+			return
+		}
+
+		var response authorizationResponse
+		switch {
+		case query.Get("error") != "":
+			http.Error(w, "Login failed, return to appctl for details.", http.StatusUnauthorized)
+			response.err = fmt.Errorf("%v: %v", query.Get("error"), query.Get("error_description"))
+		case query.Get("code") == "":
+			http.Error(w, "Missing authorization code.", http.StatusBadRequest)
+			response.err = fmt.Errorf("missing authorization code in login redirect")
+		default:
+			fmt.Fprintf(w, "Login complete, you can close this window and return to appctl.\n")
+			response.code = query.Get("code")
+		}
+		// Only the first response is used.
+		select {
+		case responses <- response:
+		default:
+		}
+	})
+	return mux
+}
+
+// To build the URL of the authorization request.
+func authorizeURL(redirectURI string, challenge string, state string) string {
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", constants.CLIENTID)
+	query.Set("redirect_uri", redirectURI)
+	query.Set("scope", constants.LOGINSCOPE)
+	query.Set("code_challenge", challenge)
+	query.Set("code_challenge_method", "S256")
+	query.Set("state", state)
+	return constants.AUTHORIZEURL + "?" + query.Encode()
+}
+
+// S256 code challenge of a code verifier, as per RFC 7636.
+func codeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Random URL safe string, from n random bytes.
+func randomURLString(n int) (string, error) {
+	data := make([]byte, n)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
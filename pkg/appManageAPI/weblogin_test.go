@@ -0,0 +1,164 @@
+package appManageAPI
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/platform9/appctl/pkg/constants"
+)
+
+const dummyAuthorizationCode = "dummyAuthorizationCode"
+
+// Fake browser for the authorization code flow. Checks the authorization
+// request and redirects back to appctl, redirect overrides the query sent back.
+func fakeBrowser(t *testing.T, redirect func(query url.Values) url.Values, challenge *string) func(string) error {
+	return func(authURL string) error {
+		parsed, err := url.Parse(authURL)
+		if err != nil {
+			t.Fatalf("invalid authorization URL: %v", err)
+		}
+		query := parsed.Query()
+		if query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" ||
+			query.Get("client_id") != constants.CLIENTID || query.Get("code_challenge") == "" {
+			t.Errorf("unexpected authorization request: %v", query)
+		}
+		*challenge = query.Get("code_challenge")
+
+		back := url.Values{"code": {dummyAuthorizationCode}, "state": {query.Get("state")}}
+		if redirect != nil {
+			back = redirect(back)
+		}
+		// Own transport, httpmock only fakes the authorization server.
+		client := &http.Client{Transport: &http.Transport{}}
+		go func() {
+			resp, err := client.Get(query.Get("redirect_uri") + "?" + back.Encode())
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+		return nil
+	}
+}
+
+// Fake token endpoint for the authorization code exchange.
+func registerCodeExchange(t *testing.T, challenge *string) {
+	httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("https://%s/oauth/token", dummyDomain), func(req *http.Request) (*http.Response, error) {
+		req.ParseForm()
+		sum := sha256.Sum256([]byte(req.PostForm.Get("code_verifier")))
+		if req.PostForm.Get("grant_type") != "authorization_code" || req.PostForm.Get("code") != dummyAuthorizationCode ||
+			base64.RawURLEncoding.EncodeToString(sum[:]) != *challenge {
+			return httpmock.NewJsonResponse(403, map[string]string{"error": "invalid_grant", "error_description": "Invalid authorization code"})
+		}
+		return httpmock.NewJsonResponse(200, map[string]interface{}{"id_token": dummyIDToken, "expires_in": 3600})
+	})
+}
+
+func TestLoginAppWeb(t *testing.T) {
+	loginWebCases := map[string]struct {
+		redirect      func(query url.Values) url.Values
+		expectedLogin bool
+	}{
+		"Success": {
+			expectedLogin: true,
+		},
+		"AccessDenied": {
+			redirect: func(query url.Values) url.Values {
+				return url.Values{"error": {"access_denied"}, "state": query["state"]}
+			},
+		},
+		"WrongCode": {
+			redirect: func(query url.Values) url.Values {
+				query.Set("code", "otherCode")
+				return query
+			},
+		},
+	}
+
+	for testName, test := range loginWebCases {
+		t.Run(testName, func(t *testing.T) {
+			useFakeAuthServer(t)
+			useTempConfig(t)
+			httpmock.RegisterResponder(http.MethodGet, "https://google.com", httpmock.NewStringResponder(200, ""))
+			httpmock.RegisterResponder(http.MethodPost, constants.APPURL+"/login", httpmock.NewStringResponder(200, ""))
+
+			var challenge string
+			openBrowser = fakeBrowser(t, test.redirect, &challenge)
+			registerCodeExchange(t, &challenge)
+
+			err := LoginAppWeb()
+			if test.expectedLogin && err != nil {
+				t.Fatalf("failed with error: %v", err)
+			}
+			if !test.expectedLogin && err == nil {
+				t.Fatalf("expected login to fail")
+			}
+
+			config, _ := loadConfig(constants.CONFIGFILEPATH)
+			if test.expectedLogin != (config.IDToken == dummyIDToken) {
+				t.Errorf("unexpected id token %q in config", config.IDToken)
+			}
+		})
+	}
+}
+
+func TestCallbackIgnoresWrongState(t *testing.T) {
+	responses := make(chan authorizationResponse, 1)
+	server := &http.Server{Handler: callbackHandler("expectedState", responses)}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	callback := fmt.Sprintf("http://%s/callback?", listener.Addr().String())
+	resp, err := http.Get(callback + url.Values{"code": {"forgedCode"}, "state": {"otherState"}}.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest || len(responses) != 0 {
+		t.Errorf("expected redirect with wrong state to be rejected, got status %v", resp.Status)
+	}
+
+	resp, err = http.Get(callback + url.Values{"code": {dummyAuthorizationCode}, "state": {"expectedState"}}.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if response := <-responses; response.err != nil || response.code != dummyAuthorizationCode {
+		t.Errorf("unexpected response: %+v", response)
+	}
+}
+
+func TestLoginAppWebFallback(t *testing.T) {
+	useFakeAuthServer(t)
+	useTempConfig(t)
+	savedListenLoopback := listenLoopback
+	listenLoopback = func() (net.Listener, error) {
+		return nil, fmt.Errorf("address already in use")
+	}
+	t.Cleanup(func() {
+		listenLoopback = savedListenLoopback
+	})
+
+	// The device flow opens the browser with the device verification URL.
+	openBrowser = func(string) error { return nil }
+	httpmock.RegisterResponder(http.MethodGet, "https://google.com", httpmock.NewStringResponder(200, ""))
+	httpmock.RegisterResponder(http.MethodPost, constants.APPURL+"/login", httpmock.NewStringResponder(200, ""))
+	server := &fakeAuthServer{responses: []string{""}}
+	server.register(t, map[string]interface{}{"device_code": dummyDeviceCode, "interval": 5, "expires_in": 900})
+
+	if err := LoginAppWeb(); err != nil {
+		t.Fatalf("failed with error: %v", err)
+	}
+	if server.polls != 1 {
+		t.Errorf("expected device flow to be used, got %d token polls", server.polls)
+	}
+}
@@ -0,0 +1,213 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/platform9/appctl/pkg/constants"
+	"github.com/platform9/appctl/pkg/secrets"
+	"github.com/platform9/appctl/pkg/settings"
+)
+
+// Outcomes of an operation.
+const (
+	Success = "success"
+	Failure = "failure"
+)
+
+// Shown in place of redacted flag values.
+const Redacted = "REDACTED"
+
+// Flags whose values are never written to the audit log.
+var secretFlagRegex = regexp.MustCompile(`(?i)password|secret|token|key|credential`)
+
+// An operation recorded in the audit log.
+type Entry struct {
+	Time      time.Time         `json:"time"`
+	User      string            `json:"user"`
+	LoginType string            `json:"loginType,omitempty"`
+	Command   string            `json:"command"`
+	Args      []string          `json:"args,omitempty"`
+	Flags     map[string]string `json:"flags,omitempty"`
+	App       string            `json:"app,omitempty"`
+	Event     string            `json:"event"`
+	Outcome   string            `json:"outcome"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// The command line that is running, set once by the command.
+type Invocation struct {
+	Command string
+	Args    []string
+	// Flags that were set, already redacted.
+	Flags map[string]string
+	// App named by the flags, for operations that fail before finding it.
+	App string
+}
+
+var (
+	lock       sync.Mutex
+	invocation Invocation
+)
+
+// To set the command line that is running, recorded with every entry.
+func SetInvocation(i Invocation) {
+	lock.Lock()
+	defer lock.Unlock()
+	invocation = i
+}
+
+// Path of the audit log.
+func Path() string {
+	return filepath.Join(constants.CONFIGDIR, constants.AUDITLOGFILE)
+}
+
+// Whether the audit log is enabled, it is opt-in.
+func Enabled() bool {
+	saved, err := settings.Load()
+	return err == nil && saved.Audit
+}
+
+// To record an operation of the running command in the audit log, if
+// enabled. The entry is appended as a single line, entries are never
+// rewritten.
+func Record(entry Entry) error {
+	if !Enabled() {
+		return nil
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	entry.Command, entry.Args, entry.Flags = invocation.Command, invocation.Args, invocation.Flags
+	if entry.App == "" {
+		entry.App = invocation.App
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(constants.CONFIGDIR, 0700); err != nil {
+		return fmt.Errorf("Failed to create config directory!!")
+	}
+	file, err := os.OpenFile(Path(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("Failed to open audit log with error: %v", err)
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// To redact the values of a flag for the audit log. Flags named like a
+// secret are redacted entirely, environment variables keep only their names.
+// Values of other flags, like the --command and --arg of the app, have the
+// words that look like secrets redacted.
+func RedactFlag(name string, values []string) string {
+	if secretFlagRegex.MatchString(name) {
+		return Redacted
+	}
+	if name == "env" {
+		var redacted []string
+		for _, value := range values {
+			key := strings.SplitN(value, "=", 2)[0]
+			redacted = append(redacted, key+"="+Redacted)
+		}
+		return strings.Join(redacted, ",")
+	}
+	previous := ""
+	redacted := make([]string, len(values))
+	for i, value := range values {
+		words := strings.Fields(value)
+		for j, word := range words {
+			words[j] = redactWord(word, previous)
+			previous = word
+		}
+		redacted[i] = value
+		if len(words) > 0 {
+			redacted[i] = strings.Join(words, " ")
+		}
+	}
+	return strings.Join(redacted, ",")
+}
+
+// To redact a word that looks like a secret, like --password=hunter2, a
+// token, or the word after --password.
+func redactWord(word string, previous string) string {
+	if pair := strings.SplitN(word, "=", 2); len(pair) == 2 && secrets.Detect(flagKey(pair[0]), pair[1]) != "" {
+		return pair[0] + "=" + Redacted
+	}
+	if strings.HasPrefix(previous, "-") && !strings.Contains(previous, "=") && secrets.IsSensitiveKey(flagKey(previous)) {
+		return Redacted
+	}
+	if secrets.SensitiveValue(word) != "" {
+		return Redacted
+	}
+	return word
+}
+
+// Name of a flag as an environment variable, --api-key as API_KEY.
+func flagKey(flag string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimLeft(flag, "-"), "-", "_"))
+}
+
+// Filter for the entries of the audit log. Zero values match everything.
+type Query struct {
+	App     string
+	Since   time.Time
+	Until   time.Time
+	Outcome string
+}
+
+func (q Query) matches(entry Entry) bool {
+	if q.App != "" && entry.App != q.App {
+		return false
+	}
+	if !q.Since.IsZero() && entry.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && entry.Time.After(q.Until) {
+		return false
+	}
+	if q.Outcome != "" && entry.Outcome != q.Outcome {
+		return false
+	}
+	return true
+}
+
+// To read the entries of the audit log matching the query, oldest first.
+// Lines that can't be parsed are skipped.
+func Read(query Query) ([]Entry, error) {
+	file, err := os.Open(Path())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to open audit log with error: %v", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if query.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
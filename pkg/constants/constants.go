@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"strings"
 )
 
 type ListAppInfo struct {
@@ -20,11 +21,45 @@ const (
 	// Time to wait to get app deployed.
 	APPDEPLOYINTERVAL = 5
 
-	// Token poll interval
+	// Seconds to wait for an app to answer appctl curl.
+	CURLTIMEOUT = 10
+
+	// Seconds to wait for the registry to check an image before deploying it.
+	IMAGECHECKTIMEOUT = 15
+
+	// Token poll interval, if the server doesn't send one.
 	TOKENPOLLINTERVAL = 5
 
-	// Fetch secure app endpoint.
-	SECUREENDPOINT = 2
+	// Seconds added to the token poll interval on a slow_down response.
+	TOKENPOLLSLOWDOWN = 5
+
+	// Device code lifetime in seconds, if the server doesn't send one.
+	DEVICECODEEXPIRY = 900
+
+	// Seconds to wait for the browser to redirect back in `appctl login --web`.
+	WEBLOGINTIMEOUT = 300
+
+	// Seconds the app names for shell completion are cached for.
+	APPNAMESCACHETTL = 30
+
+	// Seconds between checks of a new custom domain, and how many checks
+	// to wait for its certificate.
+	DOMAINPOLLINTERVAL = 5
+	DOMAINPOLLATTEMPTS = 60
+
+	// Default seconds between checks of the tag followed by deploy --follow-tag,
+	// and the most seconds to back off to while the registry fails.
+	FOLLOWTAGINTERVAL   = 30
+	FOLLOWTAGMAXBACKOFF = 300
+
+	// Checks to wait for a deleted app to be gone, APPDEPLOYINTERVAL apart.
+	APPDELETEPOLLATTEMPTS = 24
+
+	// Seconds to wait for the secret providers env values reference.
+	SECRETRESOLVETIMEOUT = 30
+
+	// Seconds to wait for usage events to be sent before exiting, the rest are spooled.
+	TELEMETRYSHUTDOWNTIMEOUT = 2
 
 	// Maximum app deployed status code.
 	MaxAppDeployStatusCode = "429"
@@ -43,10 +78,35 @@ var (
 	DOMAIN        string
 	CLIENTID      string
 	DEVICECODEURL string
+	AUTHORIZEURL  string
+	// Scopes requested at login, space separated.
+	LOGINSCOPE string
+	// Port of the loopback redirect listener for `appctl login --web`, 0 picks a free port.
+	LOGINREDIRECTPORT = "0"
+	// Endpoint of the registry credentials, a sibling of APPURL if not set.
+	REGISTRYURL string
+	// Issuer of the ID tokens, and the endpoint publishing its signing keys.
+	ISSUER  string
+	JWKSURL string
 
 	DEVICEREQUESTPAYLOAD string
 	// Grant type is urlencoded
 	GrantType string
+
+	// API identifier of the app-controller, requested for client credentials tokens.
+	AUDIENCE string
+)
+
+// Environment variables.
+var (
+	// Token to use instead of the one saved by `appctl login`, for CI.
+	TOKENENVVAR = "APPCTL_TOKEN"
+	// Client secret for `appctl login --client-id`, if not passed as a flag.
+	CLIENTSECRETENVVAR = "APPCTL_CLIENT_SECRET"
+	// Telemetry mode, one of on, off or show. Overrides `appctl telemetry`.
+	TELEMETRYENVVAR = "APPCTL_TELEMETRY"
+	// Opt out of telemetry for all tools that respect it, see https://consoledonottrack.com.
+	DONOTTRACKENVVAR = "DO_NOT_TRACK"
 )
 
 // Available SCOPES for auth0 access
@@ -82,6 +142,18 @@ var (
 	CONFIGDIR      = HOMEDIR + "/.config/pf9"
 	CONFIGFILE     = "config.json"
 	CONFIGFILEPATH = CONFIGDIR + "/" + CONFIGFILE
+	// Cached signing keys of the token issuer.
+	JWKSCACHEFILEPATH = CONFIGDIR + "/jwks.json"
+	// appctl settings, kept apart from the config rewritten on every login.
+	SETTINGSFILE = "settings.json"
+	// Usage events that failed to send, sent again on the next run.
+	TELEMETRYSPOOLFILE = "telemetry-spool.jsonl"
+	// Audit log of appctl operations, one JSON entry per line.
+	AUDITLOGFILE = "audit.log"
+	// App names cached for shell completion.
+	APPNAMESCACHEFILE = "app-names.json"
+	// Deployment policy apps are checked against, unless APPCTL_POLICY is set.
+	POLICYFILE = "policy.yaml"
 )
 
 // Regex for valid app name
@@ -89,8 +161,9 @@ var (
 	// Valid App Name to deploy.
 	ValidAppNameRegex = fmt.Sprintf(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
 
-	// Regex for matching the environment variables in .env file
-	RegexEnv = fmt.Sprintf(`[[:alnum:]]+=[[:alnum:]]+`)
+	// Regex for matching the environment variables in .env file, values can
+	// reference secrets like @file:/run/secrets/db.
+	RegexEnv = fmt.Sprintf(`[[:alnum:]]+=([[:alnum:]]|@)`)
 )
 
 // Error Messages
@@ -125,5 +198,9 @@ func getAllScope() string {
 // Composing dependent variables
 func init() {
 	DEVICECODEURL = fmt.Sprintf("https://%s/oauth/device/code", DOMAIN)
+	AUTHORIZEURL = fmt.Sprintf("https://%s/authorize", DOMAIN)
+	LOGINSCOPE = strings.TrimSpace(getAllScope())
+	ISSUER = fmt.Sprintf("https://%s/", DOMAIN)
+	JWKSURL = fmt.Sprintf("https://%s/.well-known/jwks.json", DOMAIN)
 	DEVICEREQUESTPAYLOAD = fmt.Sprintf("client_id=%s&scope=%s", CLIENTID, getAllScope())
 }
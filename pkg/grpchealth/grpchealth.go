@@ -0,0 +1,188 @@
+// Package grpchealth calls the standard gRPC health check,
+// grpc.health.v1.Health/Check, over HTTP/2 with the standard library only.
+// The request and response messages are small enough to encode by hand.
+package grpchealth
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Path of the Check method of the health service.
+const checkPath = "/grpc.health.v1.Health/Check"
+
+// Serving status of grpc.health.v1.HealthCheckResponse.
+type Status int
+
+const (
+	Unknown Status = iota
+	Serving
+	NotServing
+	ServiceUnknown
+)
+
+func (s Status) String() string {
+	switch s {
+	case Unknown:
+		return "UNKNOWN"
+	case Serving:
+		return "SERVING"
+	case NotServing:
+		return "NOT_SERVING"
+	case ServiceUnknown:
+		return "SERVICE_UNKNOWN"
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+// Error of a call the server answered with a non OK grpc-status.
+type StatusError struct {
+	Code    string
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("grpc-status %s: %s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("grpc-status %s", e.Code)
+}
+
+// To check the health of a service, or of the whole server if service is
+// empty, at the https base URL of an app. The client must speak HTTP/2,
+// as http.DefaultClient does over TLS.
+func Check(ctx context.Context, client *http.Client, baseURL string, service string) (Status, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return Unknown, err
+	}
+	if parsed.Scheme != "https" {
+		// HTTP/2 without TLS needs an h2c client, which the standard library doesn't have.
+		return Unknown, fmt.Errorf("gRPC health checks need an https URL, got %q", baseURL)
+	}
+	parsed.Path = strings.TrimSuffix(parsed.Path, "/") + checkPath
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, parsed.String(), bytes.NewReader(frame(encodeRequest(service))))
+	if err != nil {
+		return Unknown, err
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Unknown, err
+	}
+	defer resp.Body.Close()
+	if resp.ProtoMajor != 2 {
+		return Unknown, fmt.Errorf("the server answered over %s, gRPC needs HTTP/2, check the app is deployed with --protocol h2c", resp.Proto)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Unknown, fmt.Errorf("the server answered %s", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Unknown, err
+	}
+
+	// Errors without a message come as headers only, others in the trailers.
+	code, message := resp.Header.Get("Grpc-Status"), resp.Header.Get("Grpc-Message")
+	if code == "" {
+		code, message = resp.Trailer.Get("Grpc-Status"), resp.Trailer.Get("Grpc-Message")
+	}
+	if code == "" {
+		return Unknown, fmt.Errorf("the server answered without a grpc-status, it may not be a gRPC server")
+	}
+	if code != "0" {
+		message, _ = url.PathUnescape(message)
+		return Unknown, &StatusError{Code: code, Message: message}
+	}
+
+	payload, err := unframe(body)
+	if err != nil {
+		return Unknown, err
+	}
+	return decodeResponse(payload)
+}
+
+// To encode a HealthCheckRequest, field 1 is the service name.
+func encodeRequest(service string) []byte {
+	if service == "" {
+		return nil
+	}
+	message := make([]byte, 1+binary.MaxVarintLen64, 1+binary.MaxVarintLen64+len(service))
+	message[0] = 0x0a
+	n := binary.PutUvarint(message[1:], uint64(len(service)))
+	return append(message[:1+n], service...)
+}
+
+// To decode a HealthCheckResponse, field 1 is the status. Unknown fields
+// are skipped.
+func decodeResponse(message []byte) (Status, error) {
+	status := Unknown
+	for len(message) > 0 {
+		tag, n := binary.Uvarint(message)
+		if n <= 0 {
+			return Unknown, fmt.Errorf("invalid health check response")
+		}
+		message = message[n:]
+		field, wireType := tag>>3, tag&7
+		switch wireType {
+		case 0:
+			value, n := binary.Uvarint(message)
+			if n <= 0 {
+				return Unknown, fmt.Errorf("invalid health check response")
+			}
+			message = message[n:]
+			if field == 1 {
+				status = Status(value)
+			}
+		case 1, 5:
+			size := 8
+			if wireType == 5 {
+				size = 4
+			}
+			if len(message) < size {
+				return Unknown, fmt.Errorf("invalid health check response")
+			}
+			message = message[size:]
+		case 2:
+			size, n := binary.Uvarint(message)
+			if n <= 0 || uint64(len(message)-n) < size {
+				return Unknown, fmt.Errorf("invalid health check response")
+			}
+			message = message[n+int(size):]
+		default:
+			return Unknown, fmt.Errorf("invalid health check response")
+		}
+	}
+	return status, nil
+}
+
+// To prefix a message with the gRPC length prefix, uncompressed.
+func frame(message []byte) []byte {
+	framed := make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(framed[1:], uint32(len(message)))
+	return append(framed, message...)
+}
+
+// To get the message out of a length prefixed response body.
+func unframe(body []byte) ([]byte, error) {
+	if len(body) < 5 {
+		return nil, fmt.Errorf("the server answered without a health check response")
+	}
+	if body[0] != 0 {
+		return nil, fmt.Errorf("the server answered with a compressed response, which isn't supported")
+	}
+	size := binary.BigEndian.Uint32(body[1:5])
+	if uint64(len(body)-5) < uint64(size) {
+		return nil, fmt.Errorf("the server answered with a truncated health check response")
+	}
+	return body[5 : 5+size], nil
+}
@@ -0,0 +1,107 @@
+package grpchealth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// To start an HTTP/2 health server answering the services by name, in the
+// way grpc-go does.
+func newHealthServer(t *testing.T, statuses map[string]Status) *httptest.Server {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 || r.URL.Path != checkPath || r.Header.Get("Content-Type") != "application/grpc" || r.Header.Get("TE") != "trailers" {
+			t.Errorf("unexpected request %s %s %s %v", r.Proto, r.Method, r.URL.Path, r.Header)
+		}
+		body, _ := io.ReadAll(r.Body)
+		request, err := unframe(body)
+		if err != nil {
+			t.Errorf("invalid request: %v", err)
+		}
+		// The request is the service name, after its tag and length.
+		service := ""
+		if len(request) > 2 {
+			service = string(request[2:])
+		}
+		status, ok := statuses[service]
+		if !ok {
+			// Trailers-only response, as for errors.
+			w.Header().Set("Content-Type", "application/grpc")
+			w.Header().Set("Grpc-Status", "5")
+			w.Header().Set("Grpc-Message", "unknown%20service")
+			return
+		}
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+		w.Write(frame([]byte{0x08, byte(status)}))
+		w.Header().Set("Grpc-Status", "0")
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCheck(t *testing.T) {
+	server := newHealthServer(t, map[string]Status{"": Serving, "worker": NotServing})
+	checkCases := map[string]struct {
+		service        string
+		expectedStatus Status
+		expectedErr    string
+	}{
+		"Server":         {expectedStatus: Serving},
+		"NotServing":     {service: "worker", expectedStatus: NotServing},
+		"UnknownService": {service: "missing", expectedErr: "grpc-status 5: unknown service"},
+	}
+	for testName, test := range checkCases {
+		status, err := Check(context.Background(), server.Client(), server.URL, test.service)
+		if test.expectedErr != "" {
+			var statusErr *StatusError
+			if err == nil || !errors.As(err, &statusErr) || err.Error() != test.expectedErr {
+				t.Errorf("test case %s: expected error %q, got %v", testName, test.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil || status != test.expectedStatus {
+			t.Errorf("test case %s: expected %v, got %v and error %v", testName, test.expectedStatus, status, err)
+		}
+	}
+}
+
+func TestCheckHTTP1(t *testing.T) {
+	// Without HTTP/2 the server can't be a gRPC server.
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+	if _, err := Check(context.Background(), server.Client(), server.URL, ""); err == nil || !strings.Contains(err.Error(), "--protocol h2c") {
+		t.Errorf("expected an HTTP/2 error, got %v", err)
+	}
+	if _, err := Check(context.Background(), http.DefaultClient, "http://example.com", ""); err == nil || !strings.Contains(err.Error(), "https") {
+		t.Errorf("expected an https error, got %v", err)
+	}
+}
+
+func TestDecodeResponse(t *testing.T) {
+	decodeCases := map[string]struct {
+		message        []byte
+		expectedStatus Status
+		expectedErr    bool
+	}{
+		"Empty":         {message: nil, expectedStatus: Unknown},
+		"Serving":       {message: []byte{0x08, 0x01}, expectedStatus: Serving},
+		"UnknownFields": {message: []byte{0x12, 0x02, 'o', 'k', 0x08, 0x03, 0x1d, 0, 0, 0, 0}, expectedStatus: ServiceUnknown},
+		"Truncated":     {message: []byte{0x12, 0x05, 'o'}, expectedErr: true},
+		"BadVarint":     {message: []byte{0x08, 0xff}, expectedErr: true},
+	}
+	for testName, test := range decodeCases {
+		status, err := decodeResponse(test.message)
+		if (err != nil) != test.expectedErr || (err == nil && status != test.expectedStatus) {
+			t.Errorf("test case %s: expected %v and error %v, got %v and %v", testName, test.expectedStatus, test.expectedErr, status, err)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package jwks
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	// How long a fetched key set is trusted before it is fetched again.
+	DefaultCacheTTL = 24 * time.Hour
+
+	// Minimum time between two fetches triggered by an unknown key id,
+	// so a token with a bogus kid can't make us hammer the issuer.
+	DefaultRefreshInterval = time.Minute
+)
+
+// A single key as published in the issuer's JWKS document.
+type JSONWebKey struct {
+	Kid string   `json:"kid"`
+	Kty string   `json:"kty"`
+	Alg string   `json:"alg,omitempty"`
+	Use string   `json:"use,omitempty"`
+	N   string   `json:"n"`
+	E   string   `json:"e"`
+	X5c []string `json:"x5c,omitempty"`
+}
+
+// Cached copy of the JWKS document, stored in the config directory.
+type cachedKeySet struct {
+	FetchedAt time.Time    `json:"fetchedAt"`
+	Keys      []JSONWebKey `json:"keys"`
+}
+
+// KeySet fetches, caches and rotates the signing keys of a token issuer.
+type KeySet struct {
+	URL       string        // JWKS endpoint, usually https://DOMAIN/.well-known/jwks.json
+	CachePath string        // File to persist the key set to, empty disables the disk cache.
+	CacheTTL  time.Duration // How long the key set is used before fetching it again.
+	Refresh   time.Duration // Minimum interval between fetches for an unknown kid.
+	Client    *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+	// Time of the last fetch attempt, successful or not.
+	attemptedAt time.Time
+}
+
+// To create a key set for the given JWKS endpoint.
+func NewKeySet(url string, cachePath string) *KeySet {
+	return &KeySet{
+		URL:       url,
+		CachePath: cachePath,
+		CacheTTL:  DefaultCacheTTL,
+		Refresh:   DefaultRefreshInterval,
+		Client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Keyfunc returns the RSA public key a token was signed with.
+// It can be passed directly to jwt.Parse.
+func (k *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+		return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+	}
+	kid, _ := token.Header["kid"].(string)
+	return k.Key(kid)
+}
+
+// Key returns the public key for a key id, fetching the key set if needed.
+func (k *KeySet) Key(kid string) (*rsa.PublicKey, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.keys == nil {
+		k.loadCache()
+	}
+
+	// Key set expired, fetch a new one. Keep using the stale one if the issuer
+	// can't be reached, a key that was valid yesterday is better than none.
+	if k.keys == nil || time.Since(k.fetchedAt) > k.CacheTTL {
+		if err := k.fetch(); err != nil && k.keys == nil {
+			return nil, err
+		}
+	}
+
+	if key, ok := k.keys[kid]; ok {
+		return key, nil
+	}
+
+	// Unknown kid, the issuer may have rotated its keys.
+	if time.Since(k.attemptedAt) > k.Refresh {
+		if err := k.fetch(); err != nil {
+			return nil, err
+		}
+		if key, ok := k.keys[kid]; ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("No signing key found for kid %q", kid)
+}
+
+// To fetch the key set from the issuer and update the cache.
+func (k *KeySet) fetch() error {
+	k.attemptedAt = time.Now()
+
+	resp, err := k.Client.Get(k.URL)
+	if err != nil {
+		return fmt.Errorf("Failed to fetch signing keys with error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Failed to fetch signing keys, status: %v", resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("Failed to read signing keys, error: %v", err)
+	}
+
+	var document cachedKeySet
+	if err := json.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("Failed to parse signing keys with error: %v", err)
+	}
+
+	keys, err := parseKeys(document.Keys)
+	if err != nil {
+		return err
+	}
+
+	k.keys = keys
+	k.fetchedAt = time.Now()
+	document.FetchedAt = k.fetchedAt
+	k.saveCache(document)
+	return nil
+}
+
+// To load the key set from the disk cache, if present.
+func (k *KeySet) loadCache() {
+	if k.CachePath == "" {
+		return
+	}
+	data, err := ioutil.ReadFile(k.CachePath)
+	if err != nil {
+		return
+	}
+	var cached cachedKeySet
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return
+	}
+	keys, err := parseKeys(cached.Keys)
+	if err != nil {
+		return
+	}
+	k.keys = keys
+	k.fetchedAt = cached.FetchedAt
+}
+
+// To write the key set to the disk cache. Failures are ignored,
+// the cache is only an optimisation.
+func (k *KeySet) saveCache(document cachedKeySet) {
+	if k.CachePath == "" {
+		return
+	}
+	data, err := json.MarshalIndent(document, "", "    ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(k.CachePath), 0700); err != nil {
+		return
+	}
+	ioutil.WriteFile(k.CachePath, data, 0600)
+}
+
+// To convert the published keys to RSA public keys, indexed by kid.
+func parseKeys(keys []JSONWebKey) (map[string]*rsa.PublicKey, error) {
+	parsed := make(map[string]*rsa.PublicKey)
+	for _, key := range keys {
+		// Only RSA signing keys are used by the issuer.
+		if key.Kty != "RSA" || (key.Use != "" && key.Use != "sig") {
+			continue
+		}
+		publicKey, err := rsaPublicKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid signing key %q: %v", key.Kid, err)
+		}
+		parsed[key.Kid] = publicKey
+	}
+	if len(parsed) == 0 {
+		return nil, fmt.Errorf("No RSA signing keys found in key set")
+	}
+	return parsed, nil
+}
+
+// To build an RSA public key from the base64url encoded modulus and exponent.
+func rsaPublicKey(key JSONWebKey) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(key.N)
+	if err != nil {
+		return nil, fmt.Errorf("bad modulus: %v", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(key.E)
+	if err != nil {
+		return nil, fmt.Errorf("bad exponent: %v", err)
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() < 2 || exponent.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("bad exponent")
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(exponent.Int64()),
+	}, nil
+}
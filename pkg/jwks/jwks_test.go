@@ -0,0 +1,173 @@
+package jwks
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Local stand-in for the issuer's /.well-known/jwks.json endpoint.
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	keys    map[string]*rsa.PrivateKey
+	fetches int
+}
+
+func newJWKSServer(t *testing.T, kids ...string) *jwksServer {
+	s := &jwksServer{keys: map[string]*rsa.PrivateKey{}}
+	for _, kid := range kids {
+		s.addKey(t, kid)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.fetches++
+		var document cachedKeySet
+		for kid, key := range s.keys {
+			document.Keys = append(document.Keys, JSONWebKey{
+				Kid: kid,
+				Kty: "RSA",
+				Alg: "RS256",
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(document)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) addKey(t *testing.T, kid string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.mu.Lock()
+	s.keys[kid] = key
+	s.mu.Unlock()
+}
+
+func (s *jwksServer) removeKey(kid string) {
+	s.mu.Lock()
+	delete(s.keys, kid)
+	s.mu.Unlock()
+}
+
+func (s *jwksServer) sign(t *testing.T, kid string) string {
+	s.mu.Lock()
+	key := s.keys[kid]
+	s.mu.Unlock()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func (s *jwksServer) fetchCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetches
+}
+
+func TestKeyfunc(t *testing.T) {
+	server := newJWKSServer(t, "key1")
+	keySet := NewKeySet(server.URL, "")
+
+	if _, err := jwt.Parse(server.sign(t, "key1"), keySet.Keyfunc); err != nil {
+		t.Errorf("expected token to verify, got error: %v", err)
+	}
+
+	// A token signed by a key the issuer never published must be rejected.
+	other := newJWKSServer(t, "key1")
+	if _, err := jwt.Parse(other.sign(t, "key1"), keySet.Keyfunc); err == nil {
+		t.Errorf("expected token signed with an unknown key to fail")
+	}
+
+	// Unsigned tokens must be rejected.
+	unsigned, _ := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if _, err := jwt.Parse(unsigned, keySet.Keyfunc); err == nil {
+		t.Errorf("expected unsigned token to fail")
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	server := newJWKSServer(t, "key1")
+	keySet := NewKeySet(server.URL, "")
+	keySet.Refresh = 0
+
+	if _, err := keySet.Key("key1"); err != nil {
+		t.Fatalf("failed with error: %v", err)
+	}
+
+	// Issuer rotates to a new key, the unknown kid triggers a refetch.
+	server.addKey(t, "key2")
+	server.removeKey("key1")
+	if _, err := jwt.Parse(server.sign(t, "key2"), keySet.Keyfunc); err != nil {
+		t.Errorf("expected rotated key to verify, got error: %v", err)
+	}
+	if server.fetchCount() != 2 {
+		t.Errorf("expected 2 fetches, got %d", server.fetchCount())
+	}
+
+	// Unknown kids are not refetched within the refresh interval.
+	keySet.Refresh = time.Hour
+	if _, err := keySet.Key("key3"); err == nil {
+		t.Errorf("expected unknown kid to fail")
+	}
+	if server.fetchCount() != 2 {
+		t.Errorf("expected no extra fetch, got %d fetches", server.fetchCount())
+	}
+}
+
+func TestKeySetCache(t *testing.T) {
+	server := newJWKSServer(t, "key1")
+	cachePath := filepath.Join(t.TempDir(), "jwks.json")
+
+	if _, err := NewKeySet(server.URL, cachePath).Key("key1"); err != nil {
+		t.Fatalf("failed with error: %v", err)
+	}
+
+	// A new key set (a new appctl process) reads the keys from disk.
+	if _, err := NewKeySet(server.URL, cachePath).Key("key1"); err != nil {
+		t.Fatalf("failed with error: %v", err)
+	}
+	if server.fetchCount() != 1 {
+		t.Errorf("expected cached key set to be used, got %d fetches", server.fetchCount())
+	}
+
+	// An expired cache is fetched again.
+	keySet := NewKeySet(server.URL, cachePath)
+	keySet.CacheTTL = 0
+	if _, err := keySet.Key("key1"); err != nil {
+		t.Fatalf("failed with error: %v", err)
+	}
+	if server.fetchCount() != 2 {
+		t.Errorf("expected expired cache to be refetched, got %d fetches", server.fetchCount())
+	}
+
+	// The stale cache is still used if the issuer is unreachable.
+	server.Close()
+	keySet = NewKeySet(server.URL, cachePath)
+	keySet.CacheTTL = 0
+	if _, err := keySet.Key("key1"); err != nil {
+		t.Errorf("expected stale cache to be used, got error: %v", err)
+	}
+}
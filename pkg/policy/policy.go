@@ -0,0 +1,249 @@
+// Package policy checks apps against deployment rules before they are sent
+// to the server, like the registries images may come from and the labels
+// apps must have.
+package policy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/platform9/appctl/pkg/constants"
+	"github.com/platform9/appctl/pkg/registry"
+	"gopkg.in/yaml.v2"
+)
+
+// Port apps listen on when none is given, as the server defaults it.
+const DefaultPort = 8080
+
+// Deployment rules, read from a YAML policy file like:
+//
+//	rules:
+//	  - id: trusted-registries
+//	    allowedRegistries: [ghcr.io/acme]
+//	  - id: no-latest
+//	    disallowLatest: true
+type Policy struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// A rule of the policy. Each rule sets exactly one check.
+type Rule struct {
+	// ID the violations of the rule are reported with.
+	ID          string `yaml:"id"`
+	Description string `yaml:"description,omitempty"`
+
+	// Registries images may come from, a host like ghcr.io, or a host and
+	// path prefix like ghcr.io/acme.
+	AllowedRegistries []string `yaml:"allowedRegistries,omitempty"`
+	// To reject images tagged latest, or without a tag.
+	DisallowLatest bool `yaml:"disallowLatest,omitempty"`
+	// Labels apps must have, with a value.
+	RequiredLabels []string `yaml:"requiredLabels,omitempty"`
+	// Ports apps may listen on.
+	PortRange *PortRange `yaml:"portRange,omitempty"`
+	// Patterns of environment variable names apps can't set, like AWS_*.
+	ForbiddenEnv []string `yaml:"forbiddenEnv,omitempty"`
+}
+
+// Range of ports, both included.
+type PortRange struct {
+	Min int `yaml:"min"`
+	Max int `yaml:"max"`
+}
+
+// An app as deploy would create it, or as written in a manifest for
+// `appctl policy test`.
+type Request struct {
+	Name  string `yaml:"name"`
+	Image string `yaml:"image"`
+	// Port the app listens on, DefaultPort if 0.
+	Port   int               `yaml:"port,omitempty"`
+	Env    map[string]string `yaml:"env,omitempty"`
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+// A rule the request breaks.
+type Violation struct {
+	RuleID  string
+	Message string
+}
+
+var ruleIDRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// Path of the policy file, from APPCTL_POLICY or in the config directory.
+func Path() string {
+	if path := os.Getenv("APPCTL_POLICY"); path != "" {
+		return path
+	}
+	return filepath.Join(constants.CONFIGDIR, constants.POLICYFILE)
+}
+
+// To load a policy file. Returns nil without an error if the file is the
+// default one and doesn't exist, as apps aren't checked then.
+func Load(file string) (*Policy, error) {
+	explicit := file != ""
+	if !explicit {
+		file = Path()
+	}
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) && !explicit {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	policy, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy %v: %v", file, err)
+	}
+	return policy, nil
+}
+
+// To parse and validate a policy. Unknown fields are errors, so a mistyped
+// rule isn't silently ignored.
+func Parse(data []byte) (*Policy, error) {
+	policy := Policy{}
+	if err := yaml.UnmarshalStrict(data, &policy); err != nil {
+		return nil, err
+	}
+	ids := map[string]bool{}
+	for i, rule := range policy.Rules {
+		if !ruleIDRegex.MatchString(rule.ID) {
+			return nil, fmt.Errorf("rule %d: invalid id %q, use lowercase alphanumeric characters and '-'", i+1, rule.ID)
+		}
+		if ids[rule.ID] {
+			return nil, fmt.Errorf("rule %v: duplicate id", rule.ID)
+		}
+		ids[rule.ID] = true
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("rule %v: %v", rule.ID, err)
+		}
+	}
+	return &policy, nil
+}
+
+func (r *Rule) validate() error {
+	checks := 0
+	for _, set := range []bool{len(r.AllowedRegistries) > 0, r.DisallowLatest, len(r.RequiredLabels) > 0, r.PortRange != nil, len(r.ForbiddenEnv) > 0} {
+		if set {
+			checks++
+		}
+	}
+	if checks != 1 {
+		return fmt.Errorf("set exactly one of allowedRegistries, disallowLatest, requiredLabels, portRange or forbiddenEnv")
+	}
+	if r.PortRange != nil && (r.PortRange.Min < 1 || r.PortRange.Max > 65535 || r.PortRange.Min > r.PortRange.Max) {
+		return fmt.Errorf("invalid port range %d-%d", r.PortRange.Min, r.PortRange.Max)
+	}
+	for _, pattern := range r.ForbiddenEnv {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// To check the request against the rules, returning the violations in the
+// order of the rules.
+func (p *Policy) Evaluate(request Request) []Violation {
+	var violations []Violation
+	for _, rule := range p.Rules {
+		for _, message := range rule.evaluate(request) {
+			violations = append(violations, Violation{RuleID: rule.ID, Message: message})
+		}
+	}
+	return violations
+}
+
+func (r *Rule) evaluate(request Request) []string {
+	var messages []string
+	switch {
+	case len(r.AllowedRegistries) > 0 || r.DisallowLatest:
+		ref, err := registry.ParseReference(request.Image)
+		if err != nil {
+			return []string{fmt.Sprintf("invalid image %q: %v", request.Image, err)}
+		}
+		if r.DisallowLatest && ref.Digest == "" && ref.Tag == "latest" {
+			messages = append(messages, fmt.Sprintf("image %v uses the latest tag, deploy a version tag or digest", request.Image))
+		}
+		if len(r.AllowedRegistries) > 0 && !allowedRegistry(ref, r.AllowedRegistries) {
+			messages = append(messages, fmt.Sprintf("image %v isn't from an allowed registry: %v", request.Image, strings.Join(r.AllowedRegistries, ", ")))
+		}
+	case len(r.RequiredLabels) > 0:
+		for _, label := range r.RequiredLabels {
+			if request.Labels[label] == "" {
+				messages = append(messages, fmt.Sprintf("missing label %v", label))
+			}
+		}
+	case r.PortRange != nil:
+		port := request.Port
+		if port == 0 {
+			port = DefaultPort
+		}
+		if port < r.PortRange.Min || port > r.PortRange.Max {
+			messages = append(messages, fmt.Sprintf("port %d is outside %d-%d", port, r.PortRange.Min, r.PortRange.Max))
+		}
+	case len(r.ForbiddenEnv) > 0:
+		keys := make([]string, 0, len(request.Env))
+		for key := range request.Env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			for _, pattern := range r.ForbiddenEnv {
+				if matched, _ := path.Match(pattern, key); matched {
+					messages = append(messages, fmt.Sprintf("environment variable %v matches forbidden pattern %v", key, pattern))
+					break
+				}
+			}
+		}
+	}
+	return messages
+}
+
+// To check if the image is from one of the registries, or under one of the
+// registry path prefixes.
+func allowedRegistry(ref registry.Reference, allowed []string) bool {
+	image := ref.Registry + "/" + ref.Repository
+	for _, prefix := range allowed {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if ref.Registry == prefix || strings.HasPrefix(image, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// To read the app manifests of a YAML file, one per document.
+func LoadManifests(file string) ([]Request, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.SetStrict(true)
+	var requests []Request
+	for {
+		request := Request{}
+		err := decoder.Decode(&request)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest %v: %v", file, err)
+		}
+		if request.Image == "" {
+			return nil, fmt.Errorf("invalid manifest %v: app %q has no image", file, request.Name)
+		}
+		requests = append(requests, request)
+	}
+	return requests, nil
+}
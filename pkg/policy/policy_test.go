@@ -0,0 +1,131 @@
+package policy
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testPolicy = `
+rules:
+  - id: trusted-registries
+    description: Images come from our registries.
+    allowedRegistries: [ghcr.io/acme, registry.acme.com]
+  - id: no-latest
+    disallowLatest: true
+  - id: owner-labels
+    requiredLabels: [team, cost-center]
+  - id: unprivileged-ports
+    portRange: {min: 1024, max: 65535}
+  - id: no-cloud-credentials
+    forbiddenEnv: ["AWS_*", "*_SECRET"]
+`
+
+func TestParse(t *testing.T) {
+	policyCases := map[string]struct {
+		policy      string
+		expectedErr string
+	}{
+		"Valid":        {policy: testPolicy},
+		"Empty":        {policy: ""},
+		"UnknownField": {policy: "rules:\n  - id: a\n    allowedRegistry: [ghcr.io]\n", expectedErr: "field allowedRegistry not found"},
+		"NoCheck":      {policy: "rules:\n  - id: a\n", expectedErr: "set exactly one of"},
+		"TwoChecks":    {policy: "rules:\n  - id: a\n    disallowLatest: true\n    requiredLabels: [team]\n", expectedErr: "set exactly one of"},
+		"NoID":         {policy: "rules:\n  - disallowLatest: true\n", expectedErr: "invalid id"},
+		"DuplicateID":  {policy: "rules:\n  - id: a\n    disallowLatest: true\n  - id: a\n    requiredLabels: [team]\n", expectedErr: "duplicate id"},
+		"PortRange":    {policy: "rules:\n  - id: a\n    portRange: {min: 9000, max: 80}\n", expectedErr: "invalid port range"},
+		"Pattern":      {policy: "rules:\n  - id: a\n    forbiddenEnv: [\"[AWS\"]\n", expectedErr: "invalid pattern"},
+	}
+	for testName, test := range policyCases {
+		_, err := Parse([]byte(test.policy))
+		if test.expectedErr == "" {
+			if err != nil {
+				t.Errorf("test case %s: failed with error: %v", testName, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+			t.Errorf("test case %s: expected error %q, got %v", testName, test.expectedErr, err)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	policy, err := Parse([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("failed to parse policy: %v", err)
+	}
+	labels := map[string]string{"team": "payments", "cost-center": "42"}
+
+	evaluateCases := map[string]struct {
+		request  Request
+		expected []string
+	}{
+		"Compliant": {request: Request{Image: "ghcr.io/acme/api:v1", Port: 8080, Labels: labels, Env: map[string]string{"LOG_LEVEL": "debug"}}},
+		// The default port is checked when none is given.
+		"DefaultPort":      {request: Request{Image: "registry.acme.com/api:v1", Labels: labels}},
+		"ByDigest":         {request: Request{Image: "ghcr.io/acme/api@sha256:" + strings.Repeat("a", 64), Labels: labels}},
+		"OtherOrg":         {request: Request{Image: "ghcr.io/other/api:v1", Labels: labels}, expected: []string{"trusted-registries"}},
+		"PrefixOfOrg":      {request: Request{Image: "ghcr.io/acme-evil/api:v1", Labels: labels}, expected: []string{"trusted-registries"}},
+		"DockerHub":        {request: Request{Image: "nginx", Labels: labels}, expected: []string{"trusted-registries", "no-latest"}},
+		"LatestTag":        {request: Request{Image: "ghcr.io/acme/api:latest", Labels: labels}, expected: []string{"no-latest"}},
+		"MissingLabels":    {request: Request{Image: "ghcr.io/acme/api:v1", Labels: map[string]string{"team": "payments"}}, expected: []string{"owner-labels"}},
+		"PrivilegedPort":   {request: Request{Image: "ghcr.io/acme/api:v1", Port: 80, Labels: labels}, expected: []string{"unprivileged-ports"}},
+		"ForbiddenEnv":     {request: Request{Image: "ghcr.io/acme/api:v1", Labels: labels, Env: map[string]string{"AWS_ACCESS_KEY_ID": "x", "DB_SECRET": "y"}}, expected: []string{"no-cloud-credentials", "no-cloud-credentials"}},
+		"InvalidReference": {request: Request{Image: "Acme/API", Labels: labels}, expected: []string{"trusted-registries", "no-latest"}},
+	}
+	for testName, test := range evaluateCases {
+		var ids []string
+		for _, violation := range policy.Evaluate(test.request) {
+			ids = append(ids, violation.RuleID)
+		}
+		if strings.Join(ids, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("test case %s: expected violations of %v, got %v", testName, test.expected, ids)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("APPCTL_POLICY", filepath.Join(dir, "policy.yaml"))
+	// Apps aren't checked without a default policy file.
+	if policy, err := Load(""); policy != nil || err != nil {
+		t.Errorf("test case NoDefaultPolicy: expected no policy, got %v, %v", policy, err)
+	}
+	if _, err := Load(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Errorf("test case MissingPolicy: expected an error for a policy file given that doesn't exist")
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "policy.yaml"), []byte(testPolicy), 0600)
+	if policy, err := Load(""); err != nil || len(policy.Rules) != 5 {
+		t.Errorf("test case DefaultPolicy: expected 5 rules, got %v, %v", policy, err)
+	}
+}
+
+func TestLoadManifests(t *testing.T) {
+	dir := t.TempDir()
+	manifests := filepath.Join(dir, "apps.yaml")
+	ioutil.WriteFile(manifests, []byte(`
+name: api
+image: ghcr.io/acme/api:v1
+port: 8080
+labels: {team: payments}
+---
+name: worker
+image: ghcr.io/acme/worker:v1
+env: {QUEUE: jobs}
+`), 0600)
+	requests, err := LoadManifests(manifests)
+	if err != nil {
+		t.Fatalf("failed to load manifests: %v", err)
+	}
+	if len(requests) != 2 || requests[0].Labels["team"] != "payments" || requests[1].Env["QUEUE"] != "jobs" {
+		t.Errorf("unexpected manifests: %+v", requests)
+	}
+
+	ioutil.WriteFile(manifests, []byte("name: api\nimage: ghcr.io/acme/api:v1\nenvs: {A: b}\n"), 0600)
+	if _, err := LoadManifests(manifests); err == nil {
+		t.Errorf("test case UnknownField: expected an error")
+	}
+}
@@ -0,0 +1,120 @@
+package registry
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// Details of an image, from its manifests and config.
+type ImageInfo struct {
+	Reference string `json:"reference"`
+	// Digest of the manifest the tag points to, an index for multi platform images.
+	Digest    string          `json:"digest"`
+	Platforms []PlatformImage `json:"platforms"`
+	// Platform the config is of, the default platform if the image has it.
+	ConfigPlatform Platform `json:"configPlatform"`
+	Ports          []string `json:"ports"`
+	Entrypoint     []string `json:"entrypoint"`
+	Cmd            []string `json:"cmd"`
+}
+
+// Image of one platform.
+type PlatformImage struct {
+	Platform Platform `json:"platform"`
+	Digest   string   `json:"digest"`
+	// Compressed size of the layers and config, as pulled.
+	Size int64 `json:"size"`
+}
+
+// To read the manifests of each platform of an image, and the config of the
+// image for the default platform, or of the first platform without it.
+// Platforms other than the default whose manifest can't be read are skipped.
+func (c *Client) Inspect(ctx context.Context, ref Reference) (*ImageInfo, error) {
+	manifest, err := c.Manifest(ctx, ref, ref.Identifier())
+	if err != nil {
+		return nil, err
+	}
+	info := ImageInfo{Reference: ref.String(), Digest: manifest.Digest}
+
+	var configManifest *Manifest
+	var platformErr error
+	if manifest.IsIndex() {
+		for _, descriptor := range manifest.Manifests {
+			if descriptor.Platform == nil || descriptor.Platform.OS == "unknown" {
+				// Attestations and signatures, not images.
+				continue
+			}
+			platformManifest, err := c.Manifest(ctx, ref, descriptor.Digest)
+			if err != nil {
+				if matches(*descriptor.Platform, DefaultPlatform) {
+					return nil, err
+				}
+				platformErr = err
+				continue
+			}
+			info.Platforms = append(info.Platforms, PlatformImage{Platform: *descriptor.Platform, Digest: descriptor.Digest, Size: platformManifest.size()})
+			if configManifest == nil || matches(*descriptor.Platform, DefaultPlatform) && !matches(info.ConfigPlatform, DefaultPlatform) {
+				configManifest, info.ConfigPlatform = platformManifest, *descriptor.Platform
+			}
+		}
+	} else {
+		configManifest = manifest
+	}
+	if configManifest == nil && platformErr != nil {
+		// None of the platforms could be read.
+		return nil, platformErr
+	}
+	if configManifest == nil {
+		return &info, nil
+	}
+
+	config, err := c.Config(ctx, ref, configManifest)
+	if err != nil {
+		return nil, err
+	}
+	if !manifest.IsIndex() {
+		info.ConfigPlatform = config.Platform
+		info.Platforms = []PlatformImage{{Platform: config.Platform, Digest: manifest.Digest, Size: manifest.size()}}
+	}
+	for port := range config.Config.ExposedPorts {
+		info.Ports = append(info.Ports, port)
+	}
+	sort.Strings(info.Ports)
+	info.Entrypoint, info.Cmd = config.Config.Entrypoint, config.Config.Cmd
+	return &info, nil
+}
+
+// To check if the image has a variant for the platform.
+func (i *ImageInfo) Supports(platform Platform) bool {
+	for _, image := range i.Platforms {
+		if matches(image.Platform, platform) {
+			return true
+		}
+	}
+	return false
+}
+
+// Port of the image, if it exposes a single TCP port, or empty.
+func (i *ImageInfo) SinglePort() string {
+	var ports []string
+	for _, port := range i.Ports {
+		number := strings.TrimSuffix(port, "/tcp")
+		if !strings.Contains(number, "/") {
+			ports = append(ports, number)
+		}
+	}
+	if len(ports) != 1 {
+		return ""
+	}
+	return ports[0]
+}
+
+// Compressed size of an image manifest, its config and layers.
+func (m *Manifest) size() int64 {
+	size := m.Config.Size
+	for _, layer := range m.Layers {
+		size += layer.Size
+	}
+	return size
+}
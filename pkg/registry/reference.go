@@ -0,0 +1,118 @@
+package registry
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Docker Hub, for images without a registry, and the host of its API.
+const (
+	dockerHub      = "docker.io"
+	dockerHubAPI   = "registry-1.docker.io"
+	defaultTag     = "latest"
+	officialPrefix = "library/"
+)
+
+var (
+	repositoryRegex = regexp.MustCompile(`^[a-z0-9]+((\.|_|__|-+)[a-z0-9]+)*(/[a-z0-9]+((\.|_|__|-+)[a-z0-9]+)*)*$`)
+	tagRegex        = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+	digestRegex     = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+)
+
+// Reference of an image, like ghcr.io/org/image:tag or image@sha256:...
+type Reference struct {
+	// Host of the registry, with its port if any, docker.io for Docker Hub.
+	Registry   string
+	Repository string
+	// Tag of the image, latest if neither a tag nor a digest is given.
+	Tag    string
+	Digest string
+}
+
+// To parse an image reference the way docker does, images without a
+// registry are on Docker Hub.
+func ParseReference(image string) (Reference, error) {
+	ref := Reference{}
+	rest := strings.TrimSpace(image)
+	if rest == "" {
+		return ref, fmt.Errorf("empty image reference")
+	}
+
+	if i := strings.Index(rest, "@"); i >= 0 {
+		ref.Digest = rest[i+1:]
+		rest = rest[:i]
+		if !digestRegex.MatchString(ref.Digest) {
+			return ref, fmt.Errorf("invalid digest %q in image %q", ref.Digest, image)
+		}
+	}
+	// A colon after the last slash is the tag, others are the registry port.
+	if i := strings.LastIndex(rest, ":"); i > strings.LastIndex(rest, "/") {
+		ref.Tag = rest[i+1:]
+		rest = rest[:i]
+		if !tagRegex.MatchString(ref.Tag) {
+			return ref, fmt.Errorf("invalid tag %q in image %q", ref.Tag, image)
+		}
+	}
+
+	// The first component is the registry if it looks like a host.
+	if i := strings.Index(rest, "/"); i >= 0 && (strings.ContainsAny(rest[:i], ".:") || rest[:i] == "localhost") {
+		ref.Registry, ref.Repository = rest[:i], rest[i+1:]
+	} else {
+		ref.Registry, ref.Repository = dockerHub, rest
+	}
+	if ref.Registry == dockerHub && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = officialPrefix + ref.Repository
+	}
+	if !repositoryRegex.MatchString(ref.Repository) {
+		return ref, fmt.Errorf("invalid repository %q in image %q, it must be lowercase", ref.Repository, image)
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = defaultTag
+	}
+	return ref, nil
+}
+
+// Tag or digest of the manifest, the digest if both are given.
+func (r Reference) Identifier() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
+
+// To format the reference in full, with its registry.
+func (r Reference) String() string {
+	s := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// Base URL of the registry API. Registries on the local machine are
+// reached over http, as test and development registries don't have TLS.
+func (r Reference) apiURL() string {
+	host := r.Registry
+	if host == dockerHub {
+		host = dockerHubAPI
+	}
+	scheme := "https"
+	if isLocalhost(host) {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2/%s", scheme, host, r.Repository)
+}
+
+func isLocalhost(host string) bool {
+	hostname := host
+	if i := strings.Index(hostname, "]"); strings.HasPrefix(hostname, "[") && i > 0 {
+		hostname = hostname[1:i]
+	} else if i := strings.LastIndex(hostname, ":"); i >= 0 {
+		hostname = hostname[:i]
+	}
+	return hostname == "localhost" || hostname == "::1" || strings.HasPrefix(hostname, "127.")
+}
@@ -0,0 +1,47 @@
+package registry
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	referenceCases := map[string]struct {
+		image       string
+		expected    string
+		expectedURL string
+	}{
+		"Official":       {image: "nginx", expected: "docker.io/library/nginx:latest", expectedURL: "https://registry-1.docker.io/v2/library/nginx"},
+		"DockerHubUser":  {image: "jane/app:v1", expected: "docker.io/jane/app:v1", expectedURL: "https://registry-1.docker.io/v2/jane/app"},
+		"Registry":       {image: "gcr.io/knative-samples/helloworld-go", expected: "gcr.io/knative-samples/helloworld-go:latest", expectedURL: "https://gcr.io/v2/knative-samples/helloworld-go"},
+		"RegistryPort":   {image: "registry.example.com:5000/team/app:1.2.3", expected: "registry.example.com:5000/team/app:1.2.3", expectedURL: "https://registry.example.com:5000/v2/team/app"},
+		"Localhost":      {image: "localhost:5000/app:dev", expected: "localhost:5000/app:dev", expectedURL: "http://localhost:5000/v2/app"},
+		"Loopback":       {image: "127.0.0.1:5000/app", expected: "127.0.0.1:5000/app:latest", expectedURL: "http://127.0.0.1:5000/v2/app"},
+		"Digest":         {image: "ghcr.io/org/app@" + digest, expected: "ghcr.io/org/app@" + digest, expectedURL: "https://ghcr.io/v2/org/app"},
+		"TagAndDigest":   {image: "ghcr.io/org/app:v1@" + digest, expected: "ghcr.io/org/app:v1@" + digest, expectedURL: "https://ghcr.io/v2/org/app"},
+		"Uppercase":      {image: "ghcr.io/Org/App:v1"},
+		"InvalidTag":     {image: "nginx:-v1"},
+		"InvalidDigest":  {image: "nginx@sha256:abc"},
+		"Empty":          {image: " "},
+		"DoubleSlash":    {image: "ghcr.io//app"},
+		"TrailingColon":  {image: "nginx:"},
+		"SchemeIncluded": {image: "https://ghcr.io/org/app"},
+	}
+	for testName, test := range referenceCases {
+		ref, err := ParseReference(test.image)
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("test case %s: expected an error, got %v", testName, ref)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test case %s: failed with error: %v", testName, err)
+			continue
+		}
+		if ref.String() != test.expected || ref.apiURL() != test.expectedURL {
+			t.Errorf("test case %s: expected %s at %s, got %s at %s", testName, test.expected, test.expectedURL, ref, ref.apiURL())
+		}
+	}
+}
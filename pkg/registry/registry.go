@@ -0,0 +1,325 @@
+// Package registry talks to container registries over the OCI distribution
+// API, to check an image exists and can run before an app is deployed.
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Media types of the manifests, image indexes and configs accepted.
+const (
+	MediaTypeOCIManifest    = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIIndex       = "application/vnd.oci.image.index.v1+json"
+	MediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerList     = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+var manifestMediaTypes = []string{MediaTypeOCIIndex, MediaTypeDockerList, MediaTypeOCIManifest, MediaTypeDockerManifest}
+
+// Errors checking an image, wrapped with the details.
+var (
+	// The registry rejected the credentials, or needs some.
+	ErrUnauthorized = errors.New("unauthorized")
+	// The repository, tag or digest doesn't exist.
+	ErrNotFound = errors.New("not found")
+	// The image has no signature the key verifies.
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// Platform an image runs on.
+type Platform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// Platform apps run on.
+var DefaultPlatform = Platform{OS: "linux", Architecture: "amd64"}
+
+func (p Platform) String() string {
+	if p.Variant != "" {
+		return p.OS + "/" + p.Architecture + "/" + p.Variant
+	}
+	return p.OS + "/" + p.Architecture
+}
+
+// Reference to a blob or manifest of the registry.
+type Descriptor struct {
+	MediaType string    `json:"mediaType"`
+	Digest    string    `json:"digest"`
+	Size      int64     `json:"size"`
+	Platform  *Platform `json:"platform,omitempty"`
+	// Annotations, like the signature of a cosign signature layer.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// An image manifest, or an index of the manifests of each platform.
+type Manifest struct {
+	MediaType string `json:"mediaType"`
+	// Digest of the manifest, from the registry.
+	Digest string `json:"-"`
+	// Config and layers of an image manifest.
+	Config Descriptor   `json:"config"`
+	Layers []Descriptor `json:"layers"`
+	// Manifests of an index.
+	Manifests []Descriptor `json:"manifests"`
+}
+
+// To check if the manifest is an index of per platform manifests.
+func (m *Manifest) IsIndex() bool {
+	return m.MediaType == MediaTypeOCIIndex || m.MediaType == MediaTypeDockerList || len(m.Manifests) > 0
+}
+
+// Config of an image, with the platform it is built for.
+type ImageConfig struct {
+	Platform
+	Config struct {
+		ExposedPorts map[string]struct{} `json:"ExposedPorts"`
+		Entrypoint   []string            `json:"Entrypoint"`
+		Cmd          []string            `json:"Cmd"`
+		Labels       map[string]string   `json:"Labels"`
+	} `json:"config"`
+}
+
+// Client of registries, with the credentials of the image registry.
+type Client struct {
+	HTTPClient *http.Client
+	Username   string
+	Password   string
+
+	// Bearer token of the token service, reused for the following requests.
+	token string
+}
+
+// To get a manifest by tag or digest.
+func (c *Client) Manifest(ctx context.Context, ref Reference, identifier string) (*Manifest, error) {
+	resp, err := c.get(ctx, ref, "/manifests/"+identifier, manifestMediaTypes)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	manifest := Manifest{}
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest of %s: %v", ref, err)
+	}
+	if manifest.MediaType == "" {
+		manifest.MediaType = strings.Split(resp.Header.Get("Content-Type"), ";")[0]
+	}
+	manifest.Digest = resp.Header.Get("Docker-Content-Digest")
+	if manifest.Digest == "" && strings.HasPrefix(identifier, "sha256:") {
+		manifest.Digest = identifier
+	}
+	return &manifest, nil
+}
+
+// To get the config of an image manifest.
+func (c *Client) Config(ctx context.Context, ref Reference, manifest *Manifest) (*ImageConfig, error) {
+	resp, err := c.get(ctx, ref, "/blobs/"+manifest.Config.Digest, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	config := ImageConfig{}
+	if err := json.NewDecoder(resp.Body).Decode(&config); err != nil {
+		return nil, fmt.Errorf("invalid config of %s: %v", ref, err)
+	}
+	return &config, nil
+}
+
+// To get a path of the repository, authenticating as the registry asks.
+func (c *Client) get(ctx context.Context, ref Reference, path string, accept []string) (*http.Response, error) {
+	resp, err := c.do(ctx, ref.apiURL()+path, accept)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && c.token == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		if err := c.authenticate(ctx, ref, challenge); err != nil {
+			return nil, err
+		}
+		if resp, err = c.do(ctx, ref.apiURL()+path, accept); err != nil {
+			return nil, err
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp, nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		resp.Body.Close()
+		return nil, c.unauthorized(ref)
+	case http.StatusNotFound:
+		code := errorCode(resp.Body)
+		resp.Body.Close()
+		if code == "NAME_UNKNOWN" {
+			return nil, fmt.Errorf("%w: repository %s/%s doesn't exist", ErrNotFound, ref.Registry, ref.Repository)
+		}
+		if strings.HasPrefix(path, "/manifests/") {
+			return nil, fmt.Errorf("%w: %s doesn't exist, check the tag", ErrNotFound, ref)
+		}
+		return nil, fmt.Errorf("%w: %s%s", ErrNotFound, ref, path)
+	}
+	resp.Body.Close()
+	return nil, fmt.Errorf("registry %s answered %s", ref.Registry, resp.Status)
+}
+
+func (c *Client) do(ctx context.Context, rawURL string, accept []string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, mediaType := range accept {
+		req.Header.Add("Accept", mediaType)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	} else if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	return c.httpClient().Do(req)
+}
+
+// To answer the authentication challenge of the registry. Basic challenges
+// are answered with the credentials on every request, Bearer ones with a
+// token of the token service.
+func (c *Client) authenticate(ctx context.Context, ref Reference, challenge string) error {
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if c.Username == "" {
+			return fmt.Errorf("%w: %s needs a username and password", ErrUnauthorized, ref.Registry)
+		}
+		// The credentials were sent already.
+		return c.unauthorized(ref)
+	case "bearer":
+	default:
+		return fmt.Errorf("registry %s asked for unsupported authentication %q", ref.Registry, scheme)
+	}
+
+	tokenURL, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return fmt.Errorf("registry %s sent an invalid token realm %q", ref.Registry, params["realm"])
+	}
+	query := tokenURL.Query()
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull", ref.Repository)
+	}
+	query.Set("scope", scope)
+	tokenURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return c.unauthorized(ref)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("token service of %s answered %s", ref.Registry, resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("invalid token from %s: %v", ref.Registry, err)
+	}
+	c.token = token.Token
+	if c.token == "" {
+		c.token = token.AccessToken
+	}
+	if c.token == "" {
+		return fmt.Errorf("token service of %s answered without a token", ref.Registry)
+	}
+	return nil
+}
+
+func (c *Client) unauthorized(ref Reference) error {
+	if c.Username == "" {
+		return fmt.Errorf("%w: %s needs credentials to pull %s, or the repository doesn't exist", ErrUnauthorized, ref.Registry, ref.Repository)
+	}
+	return fmt.Errorf("%w: %s rejected the credentials of %s to pull %s", ErrUnauthorized, ref.Registry, c.Username, ref.Repository)
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// To parse a WWW-Authenticate header, like
+// Bearer realm="https://auth.example.com/token",service="registry".
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := map[string]string{}
+	scheme, rest := challenge, ""
+	if i := strings.Index(challenge, " "); i >= 0 {
+		scheme, rest = challenge[:i], challenge[i+1:]
+	}
+	for rest != "" {
+		rest = strings.TrimLeft(rest, " ,")
+		i := strings.Index(rest, "=")
+		if i < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(rest[:i]))
+		rest = rest[i+1:]
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else if end := strings.Index(rest, ","); end >= 0 {
+			value, rest = rest[:end], rest[end:]
+		} else {
+			value, rest = rest, ""
+		}
+		params[key] = value
+	}
+	return scheme, params
+}
+
+// Code of the first error of an error response of the registry.
+func errorCode(body io.Reader) string {
+	data, _ := ioutil.ReadAll(io.LimitReader(body, 64*1024))
+	var response struct {
+		Errors []struct {
+			Code string `json:"code"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal(data, &response) != nil || len(response.Errors) == 0 {
+		return ""
+	}
+	return response.Errors[0].Code
+}
+
+// To check if an image platform can run on the platform, a missing variant
+// matches any.
+func matches(image Platform, platform Platform) bool {
+	return image.OS == platform.OS && image.Architecture == platform.Architecture &&
+		(platform.Variant == "" || image.Variant == platform.Variant)
+}
@@ -0,0 +1,148 @@
+package registry
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// Media type of the layers of a cosign signature, and the annotation with
+// the signature of each layer.
+const (
+	MediaTypeCosignSimpleSigning = "application/vnd.dev.cosign.simplesigning.v1+json"
+	cosignSignatureAnnotation    = "dev.cosignproject.cosign/signature"
+	cosignSignatureType          = "cosign container image signature"
+)
+
+// Largest signature payload read, they are a few hundred bytes.
+const maxPayloadSize = 1 << 20
+
+// Payload signed by cosign, in the simple signing format.
+type SimpleSigning struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+}
+
+// To read a PEM encoded ECDSA public key, like the cosign.pub written by
+// cosign generate-key-pair.
+func LoadPublicKey(path string) (*ecdsa.PublicKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePublicKey(data)
+}
+
+// To parse a PEM encoded ECDSA public key.
+func ParsePublicKey(data []byte) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("not a PEM encoded public key")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	ecdsaKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported %T key, only ECDSA keys like the ones of cosign generate-key-pair are", key)
+	}
+	return ecdsaKey, nil
+}
+
+// Tag cosign stores the signatures of a manifest digest at.
+func signatureTag(digest string) string {
+	return strings.Replace(digest, ":", "-", 1) + ".sig"
+}
+
+// To verify the image manifest with digest is signed with key by cosign.
+// The signatures are read from the sha256-<hex>.sig tag of the repository,
+// one of them must be an ECDSA signature by key of a payload for the digest.
+func (c *Client) VerifySignature(ctx context.Context, ref Reference, digest string, key *ecdsa.PublicKey) error {
+	manifest, err := c.Manifest(ctx, ref, signatureTag(digest))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("%w: %s@%s isn't signed, it has no %s tag", ErrInvalidSignature, ref.Repository, digest, signatureTag(digest))
+		}
+		return err
+	}
+
+	var failures []string
+	for _, layer := range manifest.Layers {
+		if layer.MediaType != MediaTypeCosignSimpleSigning {
+			continue
+		}
+		err := c.verifyLayer(ctx, ref, digest, layer, key)
+		if err == nil {
+			return nil
+		}
+		failures = append(failures, err.Error())
+	}
+	if len(failures) == 0 {
+		return fmt.Errorf("%w: %s has no cosign signatures", ErrInvalidSignature, signatureTag(digest))
+	}
+	return fmt.Errorf("%w: no signature of %s@%s verifies with the key: %s", ErrInvalidSignature, ref.Repository, digest, strings.Join(failures, "; "))
+}
+
+// To verify one signature layer, the signature in its annotation must be of
+// the payload in its blob, and the payload must be for the digest.
+func (c *Client) verifyLayer(ctx context.Context, ref Reference, digest string, layer Descriptor, key *ecdsa.PublicKey) error {
+	signature, err := base64.StdEncoding.DecodeString(layer.Annotations[cosignSignatureAnnotation])
+	if err != nil || len(signature) == 0 {
+		return fmt.Errorf("layer %s has no valid signature annotation", layer.Digest)
+	}
+	payload, err := c.Blob(ctx, ref, layer.Digest)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256(payload)
+	if !ecdsa.VerifyASN1(key, hash[:], signature) {
+		return fmt.Errorf("signature of layer %s doesn't match the key", layer.Digest)
+	}
+
+	var signed SimpleSigning
+	if err := json.Unmarshal(payload, &signed); err != nil {
+		return fmt.Errorf("invalid payload of layer %s: %v", layer.Digest, err)
+	}
+	if signed.Critical.Type != cosignSignatureType {
+		return fmt.Errorf("payload of layer %s is a %q, not a %q", layer.Digest, signed.Critical.Type, cosignSignatureType)
+	}
+	if signed.Critical.Image.DockerManifestDigest != digest {
+		return fmt.Errorf("payload of layer %s signs %s, not %s", layer.Digest, signed.Critical.Image.DockerManifestDigest, digest)
+	}
+	return nil
+}
+
+// To get a small blob, checking it matches its digest.
+func (c *Client) Blob(ctx context.Context, ref Reference, digest string) ([]byte, error) {
+	resp, err := c.get(ctx, ref, "/blobs/"+digest, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxPayloadSize))
+	if err != nil {
+		return nil, err
+	}
+	if actual := fmt.Sprintf("sha256:%x", sha256.Sum256(data)); actual != digest {
+		return nil, fmt.Errorf("blob %s of %s has digest %s", digest, ref.Repository, actual)
+	}
+	return data, nil
+}
@@ -0,0 +1,151 @@
+package registry
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func generateKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return key
+}
+
+// To encode a public key like cosign.pub.
+func publicKeyPEM(t *testing.T, key interface{}) []byte {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+// To push a cosign signature of signedDigest by key, at the signature tag of
+// digest, like cosign sign does.
+func (r *testRegistry) pushSignature(repository string, digest string, signedDigest string, key *ecdsa.PrivateKey) {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"critical": map[string]interface{}{
+			"identity": map[string]interface{}{"docker-reference": r.host() + "/" + repository},
+			"image":    map[string]interface{}{"docker-manifest-digest": signedDigest},
+			"type":     cosignSignatureType,
+		},
+		"optional": nil,
+	})
+	r.blobs[digestOf(payload)] = payload
+	hash := sha256.Sum256(payload)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	if err != nil {
+		r.t.Fatalf("failed to sign: %v", err)
+	}
+	config := []byte("{}")
+	r.blobs[digestOf(config)] = config
+	manifest, _ := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     MediaTypeOCIManifest,
+		"config":        map[string]interface{}{"mediaType": "application/vnd.oci.image.config.v1+json", "digest": digestOf(config), "size": len(config)},
+		"layers": []interface{}{map[string]interface{}{
+			"mediaType":   MediaTypeCosignSimpleSigning,
+			"digest":      digestOf(payload),
+			"size":        len(payload),
+			"annotations": map[string]string{cosignSignatureAnnotation: base64.StdEncoding.EncodeToString(signature)},
+		}},
+	})
+	r.pushManifest(repository, signatureTag(digest), manifest)
+}
+
+func TestVerifySignature(t *testing.T) {
+	registry := newTestRegistry(t, "jane", "secret", false)
+	key := generateKey(t)
+	publicKey, err := ParsePublicKey(publicKeyPEM(t, key.Public()))
+	if err != nil {
+		t.Fatalf("failed to parse key: %v", err)
+	}
+
+	// Images with distinct configs, so their digests differ.
+	signed := registry.pushImage("org/signed", "v1", map[string]interface{}{"os": "linux", "architecture": "amd64", "author": "org/signed"})
+	registry.pushSignature("org/signed", signed, signed, key)
+	unsigned := registry.pushImage("org/unsigned", "v1", map[string]interface{}{"os": "linux", "architecture": "amd64", "author": "org/unsigned"})
+	otherKey := registry.pushImage("org/other-key", "v1", map[string]interface{}{"os": "linux", "architecture": "amd64", "author": "org/other-key"})
+	registry.pushSignature("org/other-key", otherKey, otherKey, generateKey(t))
+	// A signature of another image, copied to the signature tag of this one.
+	copied := registry.pushImage("org/copied", "v1", map[string]interface{}{"os": "linux", "architecture": "amd64", "author": "org/copied"})
+	registry.pushSignature("org/copied", copied, signed, key)
+
+	signatureCases := map[string]struct {
+		repository  string
+		digest      string
+		expectedErr string
+	}{
+		"Signed":       {repository: "org/signed", digest: signed},
+		"Unsigned":     {repository: "org/unsigned", digest: unsigned, expectedErr: "isn't signed"},
+		"OtherKey":     {repository: "org/other-key", digest: otherKey, expectedErr: "doesn't match the key"},
+		"OtherDigest":  {repository: "org/copied", digest: copied, expectedErr: "signs " + signed},
+		"NoRepository": {repository: "org/missing", digest: signed, expectedErr: "isn't signed"},
+	}
+	for testName, test := range signatureCases {
+		ref, _ := ParseReference(registry.host() + "/" + test.repository + "@" + test.digest)
+		client := Client{Username: "jane", Password: "secret"}
+		err := client.VerifySignature(context.Background(), ref, test.digest, publicKey)
+		if test.expectedErr == "" {
+			if err != nil {
+				t.Errorf("test case %s: failed with error: %v", testName, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrInvalidSignature) || !strings.Contains(err.Error(), test.expectedErr) {
+			t.Errorf("test case %s: expected an invalid signature error %q, got %v", testName, test.expectedErr, err)
+		}
+	}
+
+	// A payload that doesn't match its digest is rejected before verifying.
+	manifest := registry.manifests["org/signed"][signatureTag(signed)]
+	var signature Manifest
+	json.Unmarshal(manifest, &signature)
+	registry.blobs[signature.Layers[0].Digest] = []byte(`{"critical":{}}`)
+	ref, _ := ParseReference(registry.host() + "/org/signed:v1")
+	client := Client{Username: "jane", Password: "secret"}
+	if err := client.VerifySignature(context.Background(), ref, signed, publicKey); err == nil || !strings.Contains(err.Error(), "has digest") {
+		t.Errorf("test case TamperedPayload: expected a digest mismatch, got %v", err)
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	keyCases := map[string]struct {
+		data        []byte
+		expectedErr string
+	}{
+		"ECDSA":      {data: publicKeyPEM(t, generateKey(t).Public())},
+		"RSA":        {data: publicKeyPEM(t, rsaKey.Public()), expectedErr: "only ECDSA keys"},
+		"PrivateKey": {data: pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED SIGSTORE PRIVATE KEY", Bytes: []byte("key")}), expectedErr: "not a PEM encoded public key"},
+		"NotPEM":     {data: []byte("cosign.pub"), expectedErr: "not a PEM encoded public key"},
+	}
+	for testName, test := range keyCases {
+		_, err := ParsePublicKey(test.data)
+		if test.expectedErr == "" {
+			if err != nil {
+				t.Errorf("test case %s: failed with error: %v", testName, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+			t.Errorf("test case %s: expected error %q, got %v", testName, test.expectedErr, err)
+		}
+	}
+}
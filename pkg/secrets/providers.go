@@ -0,0 +1,132 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A source of secret values, referenced in env values as @scheme:reference,
+// like @file:/run/secrets/db.
+type Provider interface {
+	// To get the secret value of the reference.
+	Resolve(ctx context.Context, reference string) (string, error)
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]Provider{}
+)
+
+// Scheme of references, like file in @file:/run/secrets/db.
+var referenceRegex = regexp.MustCompile(`^@([a-z][a-z0-9]*):`)
+
+func init() {
+	Register("file", FileProvider{})
+	Register("env", EnvProvider{})
+	Register("cmd", CommandProvider{})
+	Register("vault", &VaultProvider{})
+}
+
+// To make a provider available for its scheme, replacing the one registered
+// for it before.
+func Register(scheme string, provider Provider) {
+	if !referenceRegex.MatchString("@" + scheme + ":") {
+		panic(fmt.Sprintf("secrets: invalid scheme %q", scheme))
+	}
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[scheme] = provider
+}
+
+// Schemes of the providers registered, sorted.
+func Schemes() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	schemes := make([]string, 0, len(providers))
+	for scheme := range providers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// To resolve a value referencing a secret, like @env:DB_PASSWORD. Returns
+// the value as is and false if it isn't a reference. Values starting with @@
+// are literal values starting with @.
+func Resolve(ctx context.Context, value string) (string, bool, error) {
+	if strings.HasPrefix(value, "@@") {
+		return value[1:], false, nil
+	}
+	match := referenceRegex.FindStringSubmatch(value)
+	if match == nil {
+		return value, false, nil
+	}
+	providersMu.RLock()
+	provider, ok := providers[match[1]]
+	providersMu.RUnlock()
+	if !ok {
+		return "", true, fmt.Errorf("unknown secret provider %q, use one of %v, or @@ for a value starting with @", match[1], strings.Join(Schemes(), ", "))
+	}
+	resolved, err := provider.Resolve(ctx, strings.TrimPrefix(value, match[0]))
+	if err != nil {
+		return "", true, fmt.Errorf("%v: %v", value, err)
+	}
+	return resolved, true, nil
+}
+
+// Secrets in files, like Docker and Kubernetes secrets mounted in /run/secrets.
+// The trailing newline of the file is dropped.
+type FileProvider struct{}
+
+func (FileProvider) Resolve(ctx context.Context, path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return trimNewline(string(data)), nil
+}
+
+// Secrets in environment variables of appctl.
+type EnvProvider struct{}
+
+func (EnvProvider) Resolve(ctx context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %v isn't set", name)
+	}
+	return value, nil
+}
+
+// Secrets printed by a command, like pass show db. The command isn't run by
+// a shell, its arguments are split on spaces. The trailing newline of the
+// output is dropped.
+type CommandProvider struct{}
+
+func (CommandProvider) Resolve(ctx context.Context, command string) (string, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", fmt.Errorf("no command")
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%v failed: %v: %v", args[0], err, message)
+		}
+		return "", fmt.Errorf("%v failed: %v", args[0], err)
+	}
+	return trimNewline(stdout.String()), nil
+}
+
+func trimNewline(value string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(value, "\n"), "\r")
+}
@@ -0,0 +1,66 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Provider of fixed secrets, to test registration.
+type staticProvider map[string]string
+
+func (p staticProvider) Resolve(ctx context.Context, reference string) (string, error) {
+	value, ok := p[reference]
+	if !ok {
+		return "", errors.New("no such secret")
+	}
+	return value, nil
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	ioutil.WriteFile(filepath.Join(dir, "db"), []byte("hunter22\n"), 0600)
+	t.Setenv("APPCTL_TEST_SECRET", "from-env")
+	Register("static", staticProvider{"db": "from-static"})
+
+	resolveCases := map[string]struct {
+		value            string
+		expected         string
+		expectedResolved bool
+		expectedErr      string
+	}{
+		"Literal":        {value: "debug", expected: "debug"},
+		"LiteralAt":      {value: "@home", expected: "@home"},
+		"Escaped":        {value: "@@file:not-a-reference", expected: "@file:not-a-reference"},
+		"File":           {value: "@file:" + filepath.Join(dir, "db"), expected: "hunter22", expectedResolved: true},
+		"MissingFile":    {value: "@file:" + filepath.Join(dir, "missing"), expectedResolved: true, expectedErr: "no such file"},
+		"Env":            {value: "@env:APPCTL_TEST_SECRET", expected: "from-env", expectedResolved: true},
+		"UnsetEnv":       {value: "@env:APPCTL_TEST_UNSET", expectedResolved: true, expectedErr: "isn't set"},
+		"Command":        {value: "@cmd:echo from cmd", expected: "from cmd", expectedResolved: true},
+		"FailingCommand": {value: "@cmd:false", expectedResolved: true, expectedErr: "false failed"},
+		"Registered":     {value: "@static:db", expected: "from-static", expectedResolved: true},
+		"Unknown":        {value: "@vualt:secret/app#pass", expectedResolved: true, expectedErr: `unknown secret provider "vualt"`},
+	}
+	for testName, test := range resolveCases {
+		value, resolved, err := Resolve(context.Background(), test.value)
+		if resolved != test.expectedResolved {
+			t.Errorf("test case %s: expected resolved %v, got %v", testName, test.expectedResolved, resolved)
+		}
+		if test.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+				t.Errorf("test case %s: expected error %q, got %v", testName, test.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test case %s: failed with error: %v", testName, err)
+			continue
+		}
+		if value != test.expected {
+			t.Errorf("test case %s: expected %q, got %q", testName, test.expected, value)
+		}
+	}
+}
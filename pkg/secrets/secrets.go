@@ -0,0 +1,148 @@
+// Package secrets tells which environment variables of an app look like
+// secrets, from their names and values, so they can be masked in output.
+package secrets
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Mask shown instead of secret values.
+const Mask = "********"
+
+// Names of variables holding secrets, as the whole name or its last part
+// after an underscore, like GITHUB_TOKEN or DB_PASSWORD.
+var sensitiveNames = []string{
+	"TOKEN", "PASSWORD", "PASSWD", "SECRET", "API_KEY", "APIKEY",
+	"ACCESS_KEY", "PRIVATE_KEY", "SECRET_KEY", "CREDENTIALS",
+}
+
+// Patterns of the keys and tokens of cloud providers and common services.
+var valuePatterns = map[string]*regexp.Regexp{
+	"AWS access key":    regexp.MustCompile(`^(AKIA|ASIA)[0-9A-Z]{16}$`),
+	"GitHub token":      regexp.MustCompile(`^(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})$`),
+	"GitLab token":      regexp.MustCompile(`^glpat-[A-Za-z0-9_-]{20,}$`),
+	"Slack token":       regexp.MustCompile(`^xox[abprs]-[A-Za-z0-9-]{10,}$`),
+	"Google API key":    regexp.MustCompile(`^AIza[0-9A-Za-z_-]{35}$`),
+	"Stripe key":        regexp.MustCompile(`^(sk|rk)_(live|test)_[0-9A-Za-z]{16,}$`),
+	"private key":       regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`),
+	"JSON web token":    regexp.MustCompile(`^eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*$`),
+	"URL with password": regexp.MustCompile(`^[a-z][a-z0-9+.-]*://[^/:@\s]+:[^/@\s]+@`),
+}
+
+// Values made of token characters only, like base64 or hex keys.
+var tokenValueRegex = regexp.MustCompile(`^[A-Za-z0-9+/=_-]+$`)
+
+// Shortest value and lowest entropy, in bits per character, of values that
+// look like random keys. Words, paths, versions, and UUIDs and hex digests,
+// at most 4 bits, stay below. Hex keys are only caught by their name.
+const (
+	minRandomLength  = 20
+	minRandomEntropy = 4.0
+)
+
+// An environment variable that looks like a secret, and why.
+type Finding struct {
+	Key    string
+	Reason string
+}
+
+// To tell if the name of a variable is one of a secret.
+func IsSensitiveKey(key string) bool {
+	return sensitiveName(key) != ""
+}
+
+// The secret name the name of a variable ends with, the longest one.
+func sensitiveName(key string) string {
+	key = strings.ToUpper(key)
+	matched := ""
+	for _, name := range sensitiveNames {
+		if (key == name || strings.HasSuffix(key, "_"+name)) && len(name) > len(matched) {
+			matched = name
+		}
+	}
+	return matched
+}
+
+// To tell if a value looks like a key or token, returning what it looks
+// like, or an empty string.
+func SensitiveValue(value string) string {
+	// Sorted, so the first matching pattern is always the same one.
+	names := make([]string, 0, len(valuePatterns))
+	for name := range valuePatterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if valuePatterns[name].MatchString(value) {
+			return name
+		}
+	}
+	if len(value) >= minRandomLength && tokenValueRegex.MatchString(value) &&
+		strings.ContainsAny(value, "0123456789") && strings.IndexFunc(value, isLetter) >= 0 &&
+		entropy(value) >= minRandomEntropy {
+		return "random key"
+	}
+	return ""
+}
+
+// To tell if a variable looks like a secret, from its name or its value.
+// Returns why, or an empty string.
+func Detect(key string, value string) string {
+	if value == "" {
+		return ""
+	}
+	if name := sensitiveName(key); name != "" {
+		return "name like *_" + name
+	}
+	if kind := SensitiveValue(value); kind != "" {
+		return "value looks like a " + kind
+	}
+	return ""
+}
+
+// To find the variables that look like secrets, sorted by name.
+func Scan(env map[string]string) []Finding {
+	var findings []Finding
+	for key, value := range env {
+		if reason := Detect(key, value); reason != "" {
+			findings = append(findings, Finding{Key: key, Reason: reason})
+		}
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i].Key < findings[j].Key })
+	return findings
+}
+
+// To replace the values in text with the mask, the longest first so a value
+// containing another is masked whole.
+func Redact(text string, values []string) string {
+	sorted := append([]string(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	for _, value := range sorted {
+		// Short values would mask unrelated text.
+		if len(value) >= 4 {
+			text = strings.ReplaceAll(text, value, Mask)
+		}
+	}
+	return text
+}
+
+func isLetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
+
+// Shannon entropy of the characters of a value, in bits per character.
+func entropy(value string) float64 {
+	counts := map[rune]int{}
+	for _, r := range value {
+		counts[r]++
+	}
+	var bits float64
+	for _, count := range counts {
+		p := float64(count) / float64(len(value))
+		bits -= p * math.Log2(p)
+	}
+	return bits
+}
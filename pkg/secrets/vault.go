@@ -0,0 +1,142 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Secrets in HashiCorp Vault, referenced as path#field like
+// secret/data/app#password. Both KV version 1 and 2 secrets are read, the
+// field can be left out of secrets with a single field.
+//
+// Unset settings are read like the vault CLI does, from VAULT_ADDR,
+// VAULT_TOKEN or ~/.vault-token, and VAULT_NAMESPACE.
+type VaultProvider struct {
+	Address    string
+	Token      string
+	Namespace  string
+	HTTPClient *http.Client
+}
+
+// Response of Vault to reading a secret.
+type vaultSecret struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []string               `json:"errors"`
+}
+
+func (v *VaultProvider) Resolve(ctx context.Context, reference string) (string, error) {
+	path, field := reference, ""
+	if i := strings.LastIndex(reference, "#"); i >= 0 {
+		path, field = reference[:i], reference[i+1:]
+	}
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return "", fmt.Errorf("no secret path, use path#field like secret/data/app#password")
+	}
+
+	address, token, err := v.settings()
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address+"/v1/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if namespace := v.namespace(); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+	client := v.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	secret := vaultSecret{}
+	body, _ := ioutil.ReadAll(resp.Body)
+	json.Unmarshal(body, &secret)
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", fmt.Errorf("secret %v doesn't exist in vault", path)
+	case resp.StatusCode == http.StatusForbidden:
+		return "", fmt.Errorf("vault denied reading %v, check the token and its policies", path)
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("vault answered %v: %v", resp.Status, strings.Join(secret.Errors, ", "))
+	}
+
+	data := secret.Data
+	// KV version 2 secrets are nested, along with their metadata.
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+	if field == "" {
+		if len(data) != 1 {
+			return "", fmt.Errorf("secret %v has fields %v, pick one with %v#field", path, strings.Join(fieldNames(data), ", "), path)
+		}
+		for name := range data {
+			field = name
+		}
+	}
+	value, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("secret %v has no field %v, only %v", path, field, strings.Join(fieldNames(data), ", "))
+	}
+	if text, ok := value.(string); ok {
+		return text, nil
+	}
+	encoded, _ := json.Marshal(value)
+	return string(encoded), nil
+}
+
+// Address and token of vault, from the environment when unset.
+func (v *VaultProvider) settings() (string, string, error) {
+	address := v.Address
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	if address == "" {
+		return "", "", fmt.Errorf("VAULT_ADDR isn't set")
+	}
+	token := v.Token
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	if token == "" {
+		home, _ := os.UserHomeDir()
+		data, err := ioutil.ReadFile(filepath.Join(home, ".vault-token"))
+		if err != nil {
+			return "", "", fmt.Errorf("VAULT_TOKEN isn't set, and there is no ~/.vault-token, login with vault login")
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	return strings.TrimSuffix(address, "/"), token, nil
+}
+
+func (v *VaultProvider) namespace() string {
+	if v.Namespace != "" {
+		return v.Namespace
+	}
+	return os.Getenv("VAULT_NAMESPACE")
+}
+
+func fieldNames(data map[string]interface{}) []string {
+	names := make([]string, 0, len(data))
+	for name := range data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
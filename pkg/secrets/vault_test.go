@@ -0,0 +1,103 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Vault stand-in, with a KV version 2 engine at secret/ and version 1 at kv/.
+func newTestVault(t *testing.T, token string) *httptest.Server {
+	secrets := map[string]interface{}{
+		"/v1/secret/data/app": map[string]interface{}{
+			"data":     map[string]interface{}{"pass": "hunter22", "user": "app"},
+			"metadata": map[string]interface{}{"version": 3},
+		},
+		"/v1/secret/data/single": map[string]interface{}{
+			"data":     map[string]interface{}{"token": "s.single"},
+			"metadata": map[string]interface{}{"version": 1},
+		},
+		"/v1/kv/app": map[string]interface{}{"pass": "kv1-pass", "port": 5432},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		if r.Header.Get("X-Vault-Namespace") != "team" {
+			t.Errorf("expected the team namespace, got %q", r.Header.Get("X-Vault-Namespace"))
+		}
+		secret, ok := secrets[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": secret})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestVaultProvider(t *testing.T) {
+	server := newTestVault(t, "s.token")
+	t.Setenv("VAULT_NAMESPACE", "team")
+
+	vaultCases := map[string]struct {
+		reference   string
+		token       string
+		expected    string
+		expectedErr string
+	}{
+		"KV2":           {reference: "secret/data/app#pass", expected: "hunter22"},
+		"KV2Other":      {reference: "/secret/data/app#user", expected: "app"},
+		"KV1":           {reference: "kv/app#pass", expected: "kv1-pass"},
+		"NotString":     {reference: "kv/app#port", expected: "5432"},
+		"SingleField":   {reference: "secret/data/single", expected: "s.single"},
+		"NoField":       {reference: "secret/data/app", expectedErr: "has fields pass, user, pick one"},
+		"MissingField":  {reference: "secret/data/app#password", expectedErr: "has no field password, only pass, user"},
+		"MissingSecret": {reference: "secret/data/other#pass", expectedErr: "doesn't exist"},
+		"Denied":        {reference: "secret/data/app#pass", token: "s.other", expectedErr: "vault denied reading"},
+		"NoPath":        {reference: "#pass", expectedErr: "no secret path"},
+	}
+	for testName, test := range vaultCases {
+		token := test.token
+		if token == "" {
+			token = "s.token"
+		}
+		// Read from the environment, like the provider registered.
+		t.Setenv("VAULT_ADDR", server.URL+"/")
+		t.Setenv("VAULT_TOKEN", token)
+		value, _, err := Resolve(context.Background(), "@vault:"+test.reference)
+		if test.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+				t.Errorf("test case %s: expected error %q, got %v", testName, test.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test case %s: failed with error: %v", testName, err)
+			continue
+		}
+		if value != test.expected {
+			t.Errorf("test case %s: expected %q, got %q", testName, test.expected, value)
+		}
+	}
+}
+
+func TestVaultSettings(t *testing.T) {
+	t.Setenv("VAULT_ADDR", "")
+	if _, err := (&VaultProvider{}).Resolve(context.Background(), "secret/data/app#pass"); err == nil || !strings.Contains(err.Error(), "VAULT_ADDR isn't set") {
+		t.Errorf("test case NoAddress: expected a missing address error, got %v", err)
+	}
+	t.Setenv("VAULT_TOKEN", "")
+	t.Setenv("HOME", t.TempDir())
+	vault := VaultProvider{Address: "http://127.0.0.1:1"}
+	if _, err := vault.Resolve(context.Background(), "secret/data/app#pass"); err == nil || !strings.Contains(err.Error(), "vault login") {
+		t.Errorf("test case NoToken: expected a missing token error, got %v", err)
+	}
+}
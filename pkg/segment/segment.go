@@ -2,8 +2,6 @@ package segment
 
 import (
 	"fmt"
-	"io"
-	"log"
 
 	"github.com/platform9/appctl/pkg/constants"
 	"gopkg.in/segmentio/analytics-go.v3"
@@ -11,20 +9,7 @@ import (
 
 var APPCTL_SEGMENT_WRITE_KEY string
 
-func SegmentClient() (analytics.Client, error) {
-	logger := log.Default()
-	logger.SetOutput(io.Discard)
-	client, err := analytics.NewWithConfig(APPCTL_SEGMENT_WRITE_KEY, analytics.Config{
-		Logger: analytics.StdLogger(logger),
-		// TODO: Possibly have custom callbacks and redirect logs to some file
-	})
-	if err != nil {
-		return nil, err
-	}
-	return client, nil
-}
-
-func SendGroupTraits(c analytics.Client, id string, data map[string]interface{}) error {
+func SendGroupTraits(c Tracker, id string, data map[string]interface{}) error {
 	userID := fmt.Sprintf("appctl-%s", id)
 
 	if err := c.Enqueue(analytics.Group{
@@ -38,8 +23,8 @@ func SendGroupTraits(c analytics.Client, id string, data map[string]interface{})
 	return nil
 }
 
-//Sent event for appctl commands specific to an app.
-func SendEvent(c analytics.Client, name string, id string, status string, loginType string, errMessage string, data []constants.ListAppInfo) error {
+// Sent event for appctl commands specific to an app.
+func SendEvent(c Tracker, name string, id string, status string, loginType string, errMessage string, data []constants.ListAppInfo) error {
 	userID := fmt.Sprintf("appctl-%s", id)
 	var data_str constants.ListAppInfo
 	if data != nil {
@@ -67,7 +52,7 @@ func SendEvent(c analytics.Client, name string, id string, status string, loginT
 	return nil
 }
 
-func SendEventList(c analytics.Client, name string, id string, status string, loginType string, errMessage string, data interface{}) error {
+func SendEventList(c Tracker, name string, id string, status string, loginType string, errMessage string, data interface{}) error {
 	userID := fmt.Sprintf("appctl-%s", id)
 	if err := c.Enqueue(analytics.Track{
 		UserId: userID,
@@ -84,8 +69,3 @@ func SendEventList(c analytics.Client, name string, id string, status string, lo
 
 	return nil
 }
-
-// To close the event.
-func Close(c analytics.Client) {
-	c.Close()
-}
@@ -0,0 +1,107 @@
+package segment
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/platform9/appctl/pkg/constants"
+	"github.com/platform9/appctl/pkg/settings"
+)
+
+// Telemetry modes.
+const (
+	TelemetryOn  = "on"
+	TelemetryOff = "off"
+	// Print the events instead of sending them.
+	TelemetryShow = "show"
+)
+
+// Shown once, before the first event is sent.
+var TelemetryNotice = `appctl collects usage data, including the app name, image and URL of the
+commands you run, to help improve it. It is tied to the account you are logged
+in with, by its email, GitHub username or client ID. To see exactly what is sent run
+"appctl telemetry show", to opt out run "appctl telemetry disable" or set
+DO_NOT_TRACK=1 or APPCTL_TELEMETRY=off.
+`
+
+// To get the telemetry mode and what set it. DO_NOT_TRACK opts out of
+// everything, then APPCTL_TELEMETRY, then the saved setting. On by default.
+func TelemetryMode() (string, string) {
+	if doNotTrack := os.Getenv(constants.DONOTTRACKENVVAR); doNotTrack != "" && doNotTrack != "0" && doNotTrack != "false" {
+		return TelemetryOff, fmt.Sprintf("%s is set", constants.DONOTTRACKENVVAR)
+	}
+	if value := os.Getenv(constants.TELEMETRYENVVAR); value != "" {
+		if mode, ok := ParseTelemetryMode(value); ok {
+			return mode, fmt.Sprintf("%s=%s", constants.TELEMETRYENVVAR, value)
+		}
+	}
+	if saved, err := settings.Load(); err == nil && saved.Telemetry != "" {
+		if mode, ok := ParseTelemetryMode(saved.Telemetry); ok {
+			return mode, "appctl telemetry"
+		}
+	}
+	return TelemetryOn, "default"
+}
+
+// To parse a telemetry mode, accepting the usual spellings of on and off.
+func ParseTelemetryMode(value string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "on", "1", "true", "enable", "enabled":
+		return TelemetryOn, true
+	case "off", "0", "false", "disable", "disabled":
+		return TelemetryOff, true
+	case "show":
+		return TelemetryShow, true
+	}
+	return "", false
+}
+
+// To print the first-run notice to w, if telemetry is on by default and the
+// notice wasn't shown yet.
+func ShowTelemetryNotice(w io.Writer) {
+	if mode, source := TelemetryMode(); mode != TelemetryOn || source != "default" {
+		return
+	}
+	saved, err := settings.Load()
+	if err != nil || saved.TelemetryNoticeShown {
+		return
+	}
+	saved.TelemetryNoticeShown = true
+	if err := saved.Save(); err != nil {
+		// Try again next time rather than show it on every run.
+		return
+	}
+	fmt.Fprintf(w, "%s\n", TelemetryNotice)
+}
+
+// Transport that prints the batches the Segment client would upload.
+type printTransport struct {
+	w io.Writer
+}
+
+func (p printTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	var payload bytes.Buffer
+	if err := json.Indent(&payload, body, "", "  "); err != nil {
+		payload.Reset()
+		payload.Write(body)
+	}
+	fmt.Fprintf(p.w, "Telemetry payload (not sent) to %s %s:\n%s\n", req.Method, req.URL, payload.String())
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+		Request:    req,
+	}, nil
+}
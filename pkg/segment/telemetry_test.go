@@ -0,0 +1,117 @@
+package segment
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/platform9/appctl/pkg/constants"
+	"github.com/platform9/appctl/pkg/settings"
+	"gopkg.in/segmentio/analytics-go.v3"
+)
+
+func useTempSettings(t *testing.T) {
+	savedConfigDir := constants.CONFIGDIR
+	constants.CONFIGDIR = t.TempDir()
+	t.Cleanup(func() {
+		constants.CONFIGDIR = savedConfigDir
+	})
+	t.Setenv(constants.DONOTTRACKENVVAR, "")
+	t.Setenv(constants.TELEMETRYENVVAR, "")
+}
+
+func TestTelemetryMode(t *testing.T) {
+	modeCases := map[string]struct {
+		doNotTrack   string
+		envMode      string
+		savedMode    string
+		expectedMode string
+	}{
+		"Default":                {expectedMode: TelemetryOn},
+		"Saved":                  {savedMode: TelemetryOff, expectedMode: TelemetryOff},
+		"SavedShow":              {savedMode: TelemetryShow, expectedMode: TelemetryShow},
+		"EnvOverridesSaved":      {envMode: "on", savedMode: TelemetryOff, expectedMode: TelemetryOn},
+		"EnvOff":                 {envMode: "off", expectedMode: TelemetryOff},
+		"EnvFalse":               {envMode: "false", expectedMode: TelemetryOff},
+		"EnvInvalid":             {envMode: "maybe", savedMode: TelemetryShow, expectedMode: TelemetryShow},
+		"DoNotTrack":             {doNotTrack: "1", expectedMode: TelemetryOff},
+		"DoNotTrackOverridesAll": {doNotTrack: "true", envMode: "on", savedMode: TelemetryOn, expectedMode: TelemetryOff},
+		"DoNotTrackZero":         {doNotTrack: "0", expectedMode: TelemetryOn},
+	}
+
+	for testName, test := range modeCases {
+		t.Run(testName, func(t *testing.T) {
+			useTempSettings(t)
+			t.Setenv(constants.DONOTTRACKENVVAR, test.doNotTrack)
+			t.Setenv(constants.TELEMETRYENVVAR, test.envMode)
+			if test.savedMode != "" {
+				if err := (&settings.Settings{Telemetry: test.savedMode}).Save(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if mode, source := TelemetryMode(); mode != test.expectedMode {
+				t.Errorf("expected mode %q, got %q from %s", test.expectedMode, mode, source)
+			}
+		})
+	}
+}
+
+func TestShowTelemetryNotice(t *testing.T) {
+	useTempSettings(t)
+	var out bytes.Buffer
+	ShowTelemetryNotice(&out)
+	if !strings.Contains(out.String(), "appctl telemetry disable") {
+		t.Errorf("expected the notice on first run, got %q", out.String())
+	}
+
+	out.Reset()
+	ShowTelemetryNotice(&out)
+	if out.Len() != 0 {
+		t.Errorf("expected the notice only once, got %q", out.String())
+	}
+
+	// Not shown to users who already chose.
+	useTempSettings(t)
+	t.Setenv(constants.TELEMETRYENVVAR, "off")
+	ShowTelemetryNotice(&out)
+	if out.Len() != 0 {
+		t.Errorf("expected no notice with telemetry off, got %q", out.String())
+	}
+}
+
+func TestNewTrackerShow(t *testing.T) {
+	useTempSettings(t)
+	t.Setenv(constants.TELEMETRYENVVAR, "show")
+	var out bytes.Buffer
+	tracker, err := NewTracker(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SendEventList(tracker, "List-Apps", "dummyUser", "Success", "device", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	tracker.Close()
+
+	for _, expected := range []string{`"event": "List-Apps"`, `"userId": "appctl-dummyUser"`, `"batch"`} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected %s in payload, got:\n%s", expected, out.String())
+		}
+	}
+}
+
+func TestNewTrackerOff(t *testing.T) {
+	useTempSettings(t)
+	appendSpool(SpoolPath(), []analytics.Message{analytics.Track{Event: "Login", UserId: "appctl-dummyUser"}})
+	t.Setenv(constants.TELEMETRYENVVAR, "off")
+	tracker, err := NewTracker(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tracker.(NopTracker); !ok {
+		t.Errorf("expected events to be dropped, got %T", tracker)
+	}
+	if _, err := os.Stat(SpoolPath()); !os.IsNotExist(err) {
+		t.Errorf("expected spooled events to be dropped when opting out")
+	}
+}
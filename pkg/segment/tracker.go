@@ -0,0 +1,284 @@
+package segment
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/platform9/appctl/pkg/constants"
+	"gopkg.in/segmentio/analytics-go.v3"
+)
+
+// Events kept in the spool at most, the oldest are dropped first.
+const maxSpooledMessages = 1000
+
+// Tracker queues usage events and sends them in the background.
+type Tracker interface {
+	// To queue an event, never waits on the network.
+	Enqueue(analytics.Message) error
+	// To send the queued events, waiting at most for the shutdown timeout.
+	Close() error
+}
+
+// Tracker that drops all events, for tests and users who opted out.
+type NopTracker struct{}
+
+func (NopTracker) Enqueue(analytics.Message) error { return nil }
+func (NopTracker) Close() error                    { return nil }
+
+// To create the tracker for the current telemetry mode. With telemetry off
+// events are dropped, with show the payload is printed to w instead of sent.
+func NewTracker(w io.Writer) (Tracker, error) {
+	mode, _ := TelemetryMode()
+	switch mode {
+	case TelemetryOff:
+		// Opting out also drops the events that are still to be sent.
+		os.Remove(SpoolPath())
+		return NopTracker{}, nil
+	case TelemetryShow:
+		return newBackgroundTracker(printTransport{w: w}, "", constants.TELEMETRYSHUTDOWNTIMEOUT*time.Second)
+	}
+	return newBackgroundTracker(nil, SpoolPath(), constants.TELEMETRYSHUTDOWNTIMEOUT*time.Second)
+}
+
+// Path of the spool of events that failed to send.
+func SpoolPath() string {
+	return filepath.Join(constants.CONFIGDIR, constants.TELEMETRYSPOOLFILE)
+}
+
+// Tracker backed by the batching Segment client. Events that fail to send,
+// or are still in flight when the shutdown timeout is reached, are spooled
+// to disk and sent by the next tracker.
+type backgroundTracker struct {
+	client  analytics.Client
+	spool   string
+	timeout time.Duration
+
+	mu      sync.Mutex
+	pending map[string]analytics.Message
+	failed  []analytics.Message
+	closed  bool
+}
+
+// To create a background tracker. A nil transport uses the default one,
+// an empty spool path disables spooling.
+func newBackgroundTracker(transport http.RoundTripper, spool string, timeout time.Duration) (*backgroundTracker, error) {
+	tracker := &backgroundTracker{
+		spool:   spool,
+		timeout: timeout,
+		pending: map[string]analytics.Message{},
+	}
+	client, err := analytics.NewWithConfig(APPCTL_SEGMENT_WRITE_KEY, analytics.Config{
+		Logger:    analytics.StdLogger(log.New(io.Discard, "", 0)),
+		Transport: transport,
+		Callback:  tracker,
+	})
+	if err != nil {
+		return nil, err
+	}
+	tracker.client = client
+
+	// Retry the events left over from earlier runs.
+	if spool != "" {
+		spooled, _ := readSpool(spool)
+		os.Remove(spool)
+		for _, msg := range spooled {
+			tracker.Enqueue(msg)
+		}
+	}
+	return tracker, nil
+}
+
+func (b *backgroundTracker) Enqueue(msg analytics.Message) error {
+	msg, id := withMessageId(msg)
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return analytics.ErrClosed
+	}
+	b.pending[id] = msg
+	b.mu.Unlock()
+
+	if err := b.client.Enqueue(msg); err != nil {
+		b.mu.Lock()
+		delete(b.pending, id)
+		b.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (b *backgroundTracker) Close() error {
+	done := make(chan error, 1)
+	go func() {
+		done <- b.client.Close()
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(b.timeout):
+		// Don't hold up the command, whatever is left is spooled.
+	}
+
+	b.mu.Lock()
+	b.closed = true
+	unsent := b.failed
+	for _, msg := range b.pending {
+		unsent = append(unsent, msg)
+	}
+	b.failed, b.pending = nil, map[string]analytics.Message{}
+	b.mu.Unlock()
+
+	if b.spool != "" && len(unsent) > 0 {
+		if spoolErr := appendSpool(b.spool, unsent); spoolErr != nil {
+			return spoolErr
+		}
+	}
+	return err
+}
+
+// Called by the Segment client once an event was sent.
+func (b *backgroundTracker) Success(msg analytics.Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.pending, messageId(msg))
+}
+
+// Called by the Segment client once an event failed to send, after retries.
+func (b *backgroundTracker) Failure(msg analytics.Message, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := messageId(msg)
+	if _, found := b.pending[id]; !found || b.closed {
+		return
+	}
+	delete(b.pending, id)
+	b.failed = append(b.failed, msg)
+}
+
+// To set a message ID and timestamp, if missing, so the event can be matched
+// in callbacks, deduplicated by Segment and keeps its time when spooled.
+func withMessageId(msg analytics.Message) (analytics.Message, string) {
+	switch m := msg.(type) {
+	case analytics.Track:
+		if m.MessageId == "" {
+			m.MessageId = newMessageId()
+		}
+		if m.Timestamp.IsZero() {
+			m.Timestamp = time.Now()
+		}
+		return m, m.MessageId
+	case analytics.Group:
+		if m.MessageId == "" {
+			m.MessageId = newMessageId()
+		}
+		if m.Timestamp.IsZero() {
+			m.Timestamp = time.Now()
+		}
+		return m, m.MessageId
+	}
+	return msg, ""
+}
+
+func messageId(msg analytics.Message) string {
+	switch m := msg.(type) {
+	case analytics.Track:
+		return m.MessageId
+	case analytics.Group:
+		return m.MessageId
+	}
+	return ""
+}
+
+func newMessageId() string {
+	data := make([]byte, 16)
+	rand.Read(data)
+	return hex.EncodeToString(data)
+}
+
+// An event in the spool, one per line.
+type spooledMessage struct {
+	Type    string          `json:"type"`
+	Message json.RawMessage `json:"message"`
+}
+
+// To append events to the spool, keeping at most maxSpooledMessages.
+func appendSpool(path string, msgs []analytics.Message) error {
+	spooled, _ := readSpool(path)
+	spooled = append(spooled, msgs...)
+	if len(spooled) > maxSpooledMessages {
+		spooled = spooled[len(spooled)-maxSpooledMessages:]
+	}
+
+	var lines []string
+	for _, msg := range spooled {
+		var entry spooledMessage
+		switch msg.(type) {
+		case analytics.Track:
+			entry.Type = "track"
+		case analytics.Group:
+			entry.Type = "group"
+		default:
+			continue
+		}
+		data, err := json.Marshal(msg)
+		if err != nil {
+			continue
+		}
+		entry.Message = data
+		line, err := json.Marshal(entry)
+		if err != nil {
+			continue
+		}
+		lines = append(lines, string(line))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("Failed to create config directory!!")
+	}
+	return ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+}
+
+// To read the events in the spool, skipping lines that can't be parsed.
+func readSpool(path string) ([]analytics.Message, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var msgs []analytics.Message
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry spooledMessage
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		switch entry.Type {
+		case "track":
+			var msg analytics.Track
+			if json.Unmarshal(entry.Message, &msg) == nil {
+				msgs = append(msgs, msg)
+			}
+		case "group":
+			var msg analytics.Group
+			if json.Unmarshal(entry.Message, &msg) == nil {
+				msgs = append(msgs, msg)
+			}
+		}
+	}
+	return msgs, scanner.Err()
+}
@@ -0,0 +1,138 @@
+package segment
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gopkg.in/segmentio/analytics-go.v3"
+)
+
+// Fake Segment API, records the message IDs of the uploaded batches.
+type fakeSegment struct {
+	mu       sync.Mutex
+	received []string
+	fail     bool
+	block    chan struct{}
+}
+
+func (f *fakeSegment) RoundTrip(req *http.Request) (*http.Response, error) {
+	if f.block != nil {
+		<-f.block
+	}
+	if f.fail {
+		return nil, fmt.Errorf("network unreachable")
+	}
+	var batch struct {
+		Batch []struct {
+			MessageId string `json:"messageId"`
+		} `json:"batch"`
+	}
+	body, _ := ioutil.ReadAll(req.Body)
+	json.Unmarshal(body, &batch)
+	f.mu.Lock()
+	for _, msg := range batch.Batch {
+		f.received = append(f.received, msg.MessageId)
+	}
+	f.mu.Unlock()
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+}
+
+func enqueueEvents(t *testing.T, tracker Tracker, events ...string) {
+	for _, event := range events {
+		if err := tracker.Enqueue(analytics.Track{Event: event, UserId: "appctl-dummyUser"}); err != nil {
+			t.Fatalf("failed to enqueue %s: %v", event, err)
+		}
+	}
+}
+
+func spooledEvents(t *testing.T, path string) []string {
+	spooled, _ := readSpool(path)
+	var events []string
+	for _, msg := range spooled {
+		events = append(events, msg.(analytics.Track).Event)
+	}
+	return events
+}
+
+func TestTrackerSpoolsFailedEvents(t *testing.T) {
+	spool := t.TempDir() + "/spool.jsonl"
+
+	offline := &fakeSegment{fail: true}
+	tracker, err := newBackgroundTracker(offline, spool, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enqueueEvents(t, tracker, "Login", "List-Apps")
+	tracker.Close()
+	if events := spooledEvents(t, spool); len(events) != 2 {
+		t.Fatalf("expected 2 spooled events, got %v", events)
+	}
+	spooled, _ := readSpool(spool)
+
+	// The next run sends the spooled events along with its own.
+	online := &fakeSegment{}
+	tracker, err = newBackgroundTracker(online, spool, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enqueueEvents(t, tracker, "Deploy-App")
+	if err := tracker.Close(); err != nil {
+		t.Errorf("failed with error: %v", err)
+	}
+	if len(online.received) != 3 {
+		t.Errorf("expected 3 events to be sent, got %v", online.received)
+	}
+	for _, msg := range spooled {
+		if !strings.Contains(strings.Join(online.received, " "), messageId(msg)) {
+			t.Errorf("expected spooled event %s to be sent with the same message ID", messageId(msg))
+		}
+	}
+	if events := spooledEvents(t, spool); len(events) != 0 {
+		t.Errorf("expected empty spool, got %v", events)
+	}
+}
+
+func TestTrackerShutdownTimeout(t *testing.T) {
+	spool := t.TempDir() + "/spool.jsonl"
+	hanging := &fakeSegment{block: make(chan struct{})}
+	defer close(hanging.block)
+
+	tracker, err := newBackgroundTracker(hanging, spool, 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enqueueEvents(t, tracker, "Login")
+
+	start := time.Now()
+	tracker.Close()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected close to give up after the timeout, took %v", elapsed)
+	}
+	if events := spooledEvents(t, spool); len(events) != 1 || events[0] != "Login" {
+		t.Errorf("expected the unsent event to be spooled, got %v", events)
+	}
+	if err := tracker.Enqueue(analytics.Track{Event: "Login", UserId: "appctl-dummyUser"}); err == nil {
+		t.Errorf("expected enqueue after close to fail")
+	}
+}
+
+func TestSpoolLimit(t *testing.T) {
+	spool := t.TempDir() + "/spool.jsonl"
+	var msgs []analytics.Message
+	for i := 0; i < maxSpooledMessages+10; i++ {
+		msgs = append(msgs, analytics.Track{Event: fmt.Sprintf("event-%d", i), UserId: "appctl-dummyUser"})
+	}
+	if err := appendSpool(spool, msgs); err != nil {
+		t.Fatal(err)
+	}
+	events := spooledEvents(t, spool)
+	if len(events) != maxSpooledMessages || events[0] != "event-10" {
+		t.Errorf("expected the newest %d events to be kept, got %d starting with %v", maxSpooledMessages, len(events), events[0])
+	}
+}
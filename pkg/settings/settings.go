@@ -0,0 +1,55 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/platform9/appctl/pkg/constants"
+)
+
+// Settings of appctl that outlive a login, stored in the config directory.
+type Settings struct {
+	// Telemetry mode chosen with `appctl telemetry`, empty if never chosen.
+	Telemetry string `json:"telemetry,omitempty"`
+	// Whether the first-run telemetry notice was shown.
+	TelemetryNoticeShown bool `json:"telemetryNoticeShown,omitempty"`
+	// Whether operations are recorded in the audit log, off unless enabled.
+	Audit bool `json:"audit,omitempty"`
+}
+
+// Path of the settings file.
+func Path() string {
+	return filepath.Join(constants.CONFIGDIR, constants.SETTINGSFILE)
+}
+
+// To load the settings, a missing settings file gives the defaults.
+func Load() (*Settings, error) {
+	data, err := ioutil.ReadFile(Path())
+	if os.IsNotExist(err) {
+		return &Settings{}, nil
+	}
+	if err != nil {
+		return &Settings{}, fmt.Errorf("Failed to read settings with error: %v", err)
+	}
+
+	settings := Settings{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return &Settings{}, fmt.Errorf("Failed to parse settings with error: %v", err)
+	}
+	return &settings, nil
+}
+
+// To save the settings, creating the config directory if needed.
+func (s *Settings) Save() error {
+	if err := os.MkdirAll(constants.CONFIGDIR, 0700); err != nil {
+		return fmt.Errorf("Failed to create config directory!!")
+	}
+	data, err := json.MarshalIndent(s, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(Path(), data, 0600)
+}